@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+)
+
+// Supported git backends
+const (
+	backendCLI    = "cli"
+	backendNative = "native"
+)
+
+// UnknownBackend error indicates the requested git backend doesn't exist
+type UnknownBackend struct {
+	backend string
+}
+
+func (u UnknownBackend) Error() string {
+	return fmt.Sprintf("Unknown backend %q, use %v or %v", u.backend, backendCLI, backendNative)
+}
+
+// gitBackend is the GitWrapper selected with the backend flag. Since flags are only parsed once the command is
+// executed, the backend is selected from the pre-run hook
+type gitBackend struct {
+	GitWrapper
+	notesTip *string // Tip of the notes ref before the command changed anything, nil if unknown
+}
+
+// newGitBackend returns a GitWrapper, for which the implementation is selected later using the backend flag
+func newGitBackend() *gitBackend {
+	return &gitBackend{}
+}
+
+func (b *gitBackend) selectBackend(backend string) error {
+	switch backend {
+	case backendCLI:
+		b.GitWrapper = ginokeva.NewCLIGitWrapper()
+	case backendNative:
+		b.GitWrapper = ginokeva.NewNativeGitWrapper()
+	default:
+		return &UnknownBackend{backend: backend}
+	}
+	return nil
+}
+
+// recordNotesTip remembers the tip of the notes ref, so the changes the command makes can be rolled back
+func (b *gitBackend) recordNotesTip(store *ginokeva.Store) {
+	tip, err := store.NotesTip()
+	if err != nil {
+		log.WithError(err).Debug("Cannot determine tip of notes")
+		return
+	}
+	b.notesTip = &tip
+}
+
+// rollbackNotes discards the changes made to the notes since recordNotesTip. Used before retrying a command whose
+// push was rejected by every remote, since fetching again merges the local notes, which would otherwise apply the
+// changes twice
+func (b *gitBackend) rollbackNotes(store *ginokeva.Store) error {
+	if b.notesTip == nil {
+		return nil
+	}
+	return store.ResetNotes(*b.notesTip)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClones creates a remote repository with a single commit, and returns the directories of two clones of it
+func newTestClones(t *testing.T) (string, string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	git := func(dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	git(dir, "init", "-q", "--bare", "remote.git")
+	clones := []string{}
+	for _, name := range []string{"a", "b"} {
+		git(dir, "clone", "-q", "remote.git", name)
+		clone := filepath.Join(dir, name)
+		git(clone, "config", "user.name", "Test")
+		git(clone, "config", "user.email", "test@example.com")
+		clones = append(clones, clone)
+	}
+
+	git(clones[0], "commit", "-q", "--allow-empty", "-m", "Initial commit")
+	git(clones[0], "push", "-q", "origin", "HEAD")
+	git(clones[1], "pull", "-q")
+
+	return clones[0], clones[1]
+}
+
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestCommandsAgainstBackends(t *testing.T) {
+	for _, backend := range []string{backendCLI, backendNative} {
+		t.Run(backend, func(t *testing.T) {
+			a, b := newTestClones(t)
+
+			run := func(dir string, args ...string) string {
+				chdir(t, dir)
+				ctx := ContextWithGitWrapper(context.Background(), newGitBackend())
+				output, err := executeCommandContext(ctx, NewRootCommand(), append([]string{"--backend", backend}, args...)...)
+				assert.NoError(t, err, strings.Join(args, " "))
+				return output
+			}
+
+			run(a, "set", "foo", "bar", "--push")
+			run(a, "set", "counter", "1", "--push")
+			run(a, "incr", "counter", "--push")
+			assert.Equal(t, "counter=2\nfoo=bar\n", run(a, "list"))
+			assert.Equal(t, "counter=2\nfoo=bar\n", run(b, "list"))
+
+			run(b, "unset", "foo", "--push")
+			assert.Equal(t, "counter=2\n", run(a, "list"))
+
+			// Unpushed local changes are merged with upstream changes
+			run(a, "set", "local", "change")
+			run(b, "set", "remote", "change", "--push")
+			assert.Equal(t, "counter=2\nlocal=change\nremote=change\n", run(a, "list"))
+
+			run(a, "compact", "--push")
+			assert.Equal(t, "counter=2\nlocal=change\nremote=change\n", run(b, "list"))
+		})
+	}
+}
+
+func TestUnknownBackend(t *testing.T) {
+	ctx := ContextWithGitWrapper(context.Background(), newGitBackend())
+	_, err := executeCommandContext(ctx, NewRootCommand(), "list", "--backend", "unknown")
+
+	assert.IsType(t, &UnknownBackend{}, err)
+}
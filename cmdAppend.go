@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/spf13/cobra"
+)
+
+func addAppendCommandTo(root *cobra.Command) {
+	root.AddCommand(newListItemCommand(event.Append, "append", "Append one or more items to the list value of a key"))
+}
+
+func addRemoveCommandTo(root *cobra.Command) {
+	root.AddCommand(newListItemCommand(event.Remove, "remove", "Remove one or more items from the list value of a key"))
+}
+
+func newListItemCommand(eventType event.Type, use string, short string) *cobra.Command {
+	var (
+		push bool
+	)
+
+	var listItemCommand = &cobra.Command{
+		Use:   fmt.Sprintf("%s [key] [item]...", use),
+		Short: short,
+		Long: short + `.
+Items are tracked individually, so changes to different items made on different branches are all retained.
+A key which isn't set yet starts as an empty list`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			key := args[0]
+			items := args[1:]
+
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
+
+			if globalFlags.Fetch {
+				err = store.Fetch()
+				if err != nil {
+					return err
+				}
+			}
+
+			if eventType == event.Append {
+				err = store.Append(key, items...)
+			} else {
+				err = store.Remove(key, items...)
+			}
+			if err != nil {
+				return err
+			}
+
+			err = store.Prune()
+			if err != nil {
+				return err
+			}
+
+			if push {
+				err = store.Push()
+			}
+
+			return err
+		},
+		Args: cobra.MinimumNArgs(2),
+	}
+
+	listItemCommand.Flags().BoolVar(&push, "push", false, "Push notes to upstream")
+	return listItemCommand
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListItemCommand(t *testing.T) {
+	testCases := []struct {
+		name         string
+		args         []string
+		startEvents  []event.Event
+		wantedEvents []event.Event
+	}{
+		{
+			name:         "Append item",
+			startEvents:  []event.Event{},
+			args:         []string{"append", "regions", "eu"},
+			wantedEvents: []event.Event{event.TestDataAppendRegionsEU},
+		},
+		{
+			name:         "Append multiple items at once",
+			startEvents:  []event.Event{},
+			args:         []string{"append", "regions", "eu", "us"},
+			wantedEvents: []event.Event{event.TestDataAppendRegionsUS, event.TestDataAppendRegionsEU},
+		},
+		{
+			name:         "Remove item",
+			startEvents:  []event.Event{event.TestDataAppendRegionsEU},
+			args:         []string{"remove", "regions", "eu"},
+			wantedEvents: []event.Event{event.TestDataRemoveRegionsEU, event.TestDataAppendRegionsEU},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", tc.startEvents)
+
+				args := disableFetch(tc.args)
+				_, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, eventsJSON(t, tc.wantedEvents), repo.note("HEAD"))
+			})
+		})
+	}
+}
+
+func TestListItemCommandNotAList(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		startEvents := []event.Event{event.TestDataSetKeyValue}
+		repo := newTestRepo(t, backend)
+		repo.setNote("HEAD", startEvents)
+
+		args := disableFetch([]string{"append", "key", "eu"})
+		_, err := repo.run(args...)
+
+		if assert.Error(t, err) {
+			assert.IsType(t, &ginokeva.NotAList{}, err)
+		}
+		assert.Equal(t, eventsJSON(t, startEvents), repo.note("HEAD"), "Note should be unchanged")
+	})
+}
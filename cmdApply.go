@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/spf13/cobra"
+)
+
+// InvalidOperation error indicates a line of operations input could not be parsed
+type InvalidOperation struct {
+	line int
+	msg  string
+}
+
+func (i InvalidOperation) Error() string {
+	return fmt.Sprintf("Invalid operation on line %d: %v", i.line, i.msg)
+}
+
+func addApplyCommandTo(root *cobra.Command) {
+	var (
+		push bool
+	)
+
+	var applyCommand = &cobra.Command{
+		Use:   "apply [file|-]",
+		Short: "Apply a list of set/unset operations",
+		Long: `Apply a list of operations read from a file, or from stdin if no file or - is provided.
+Each line holds a single operation: "set key=value" or "unset key". Empty lines
+and lines starting with # are ignored. All operations are written in a single note update`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var input io.Reader = cmd.InOrStdin()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				input = f
+			}
+
+			operations, err := parseOperations(input)
+			if err != nil {
+				return err
+			}
+
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
+
+			if globalFlags.Fetch {
+				err = store.Fetch()
+				if err != nil {
+					return err
+				}
+			}
+
+			err = store.Apply(operations)
+			if err != nil {
+				return err
+			}
+
+			err = store.Prune()
+			if err != nil {
+				return err
+			}
+
+			if push {
+				err = store.Push()
+			}
+
+			return err
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	applyCommand.Flags().BoolVar(&push, "push", false, "Push notes to upstream")
+	root.AddCommand(applyCommand)
+}
+
+// parseOperations parses and validates the operations, so invalid input is reported with its line number
+func parseOperations(r io.Reader) (operations []ginokeva.Operation, err error) {
+	operations = []ginokeva.Operation{}
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, &InvalidOperation{line: lineNumber, msg: "expected operation and argument"}
+		}
+
+		var o ginokeva.Operation
+		switch fields[0] {
+		case "set":
+			kv, err := parseKeyValue(fields[1])
+			if err != nil {
+				return nil, &InvalidOperation{line: lineNumber, msg: err.Error()}
+			}
+			_, err = event.NewSetEvent(kv.Key, kv.Value)
+			if err != nil {
+				return nil, &InvalidOperation{line: lineNumber, msg: err.Error()}
+			}
+			o = ginokeva.Operation{Key: kv.Key, Value: kv.Value}
+		case "unset":
+			key := strings.TrimSpace(fields[1])
+			_, err = event.NewUnsetEvent(key)
+			if err != nil {
+				return nil, &InvalidOperation{line: lineNumber, msg: err.Error()}
+			}
+			o = ginokeva.Operation{Unset: true, Key: key}
+		default:
+			return nil, &InvalidOperation{line: lineNumber, msg: fmt.Sprintf("unknown operation %v", fields[0])}
+		}
+
+		operations = append(operations, o)
+	}
+
+	return operations, scanner.Err()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyCommand(t *testing.T) {
+	testCases := []struct {
+		name         string
+		input        string
+		startEvents  []event.Event
+		wantedEvents []event.Event
+	}{
+		{
+			name:         "Apply nothing",
+			input:        "",
+			startEvents:  []event.Event{event.TestDataSetKeyValue},
+			wantedEvents: []event.Event{event.TestDataSetKeyValue},
+		},
+		{
+			name:         "Apply set and unset operations",
+			input:        "# Comment\nset foo=bar\n\nunset key\n",
+			startEvents:  []event.Event{event.TestDataSetKeyValue},
+			wantedEvents: []event.Event{event.TestDataUnsetKey, event.TestDataSetFooBar, event.TestDataSetKeyValue},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", tc.startEvents)
+
+				args := disableFetch([]string{"apply", "-"})
+				_, err := repo.runWithInput(tc.input, args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, eventsJSON(t, tc.wantedEvents), repo.note("HEAD"))
+			})
+		})
+	}
+}
+
+func TestParseOperationsInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "Unknown operation",
+			input: "get foo",
+		},
+		{
+			name:  "Missing argument",
+			input: "unset",
+		},
+		{
+			name:  "Set without value",
+			input: "set foo",
+		},
+		{
+			name:  "Invalid key",
+			input: "set foo=bar\nunset 2foo",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseOperations(strings.NewReader(tc.input))
+
+			if assert.Error(t, err) {
+				assert.IsType(t, &InvalidOperation{}, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/spf13/cobra"
+)
+
+func addCasCommandTo(root *cobra.Command) {
+	var (
+		push      bool
+		valueType string
+		message   string
+	)
+
+	var casCommand = &cobra.Command{
+		Use:   "cas [key] [expected] [new]",
+		Short: "Compare-and-set the value of a key",
+		Long: `Set the value of a key to new, but only if its current value equals expected.
+Exits with code 3 if the current value doesn't match. The comparison is done again when
+the command is retried because upstream changed`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			key, expected, value := args[0], args[1], args[2]
+
+			t, err := event.ParseValueType(valueType)
+			if err != nil {
+				return err
+			}
+
+			gitWrapper := GetGitWrapperFrom(cmd.Context())
+			store := newStore(gitWrapper)
+
+			if globalFlags.Fetch {
+				err = store.Fetch()
+				if err != nil {
+					return err
+				}
+			}
+
+			metadata := collectMetadata(gitWrapper, message)
+			err = store.SetIf([]ginokeva.KeyValue{{Key: key, Value: value, ValueType: t}}, ginokeva.Precondition{Expected: &expected}, metadata)
+			if err != nil {
+				return err
+			}
+
+			err = store.Prune()
+			if err != nil {
+				return err
+			}
+
+			if push {
+				err = store.Push()
+			}
+
+			return err
+		},
+		Args: cobra.ExactArgs(3),
+	}
+
+	casCommand.Flags().BoolVar(&push, "push", false, "Push notes to upstream")
+	casCommand.Flags().StringVarP(&message, "message", "m", "", "Record a message explaining the change")
+	casCommand.Flags().StringVar(&valueType, "type", "string", "Set value type (string/int/bool/json/list)")
+	root.AddCommand(casCommand)
+}
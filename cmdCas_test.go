@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionalSet(t *testing.T) {
+	testCases := []struct {
+		name         string
+		args         []string
+		startEvents  []event.Event
+		wantedEvents []event.Event
+		wantedError  error
+	}{
+		{
+			name:         "cas with matching value",
+			startEvents:  []event.Event{event.TestDataSetKeyValue},
+			args:         []string{"cas", "key", "value", "otherValue"},
+			wantedEvents: []event.Event{event.TestDataSetKeyOtherValue, event.TestDataSetKeyValue},
+		},
+		{
+			name:        "cas with mismatching value",
+			startEvents: []event.Event{event.TestDataSetKeyOtherValue},
+			args:        []string{"cas", "key", "value", "otherValue"},
+			wantedError: &ginokeva.PreconditionFailed{},
+		},
+		{
+			name:        "cas with absent key",
+			startEvents: []event.Event{},
+			args:        []string{"cas", "key", "value", "otherValue"},
+			wantedError: &ginokeva.PreconditionFailed{},
+		},
+		{
+			name:         "set --if-absent with absent key",
+			startEvents:  []event.Event{},
+			args:         []string{"set", "key", "value", "--if-absent"},
+			wantedEvents: []event.Event{event.TestDataSetKeyValue},
+		},
+		{
+			name:        "set --if-absent with present key",
+			startEvents: []event.Event{event.TestDataSetKeyValue},
+			args:        []string{"set", "key", "otherValue", "--if-absent"},
+			wantedError: &ginokeva.PreconditionFailed{},
+		},
+		{
+			name:         "set --if-equals with matching value",
+			startEvents:  []event.Event{event.TestDataSetKeyValue},
+			args:         []string{"set", "key", "otherValue", "--if-equals", "value"},
+			wantedEvents: []event.Event{event.TestDataSetKeyOtherValue, event.TestDataSetKeyValue},
+		},
+		{
+			name:        "set --if-equals with one of multiple keys mismatching",
+			startEvents: []event.Event{event.TestDataSetKeyValue},
+			args:        []string{"set", "key=otherValue", "foo=bar", "--if-equals", "value"},
+			wantedError: &ginokeva.PreconditionFailed{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", tc.startEvents)
+				startNote := repo.note("HEAD")
+
+				args := disableFetch(tc.args)
+				_, err := repo.run(args...)
+
+				if tc.wantedError != nil {
+					if assert.Error(t, err) {
+						assert.IsType(t, tc.wantedError, err)
+					}
+					assert.Equal(t, startNote, repo.note("HEAD"), "Note should be unchanged")
+				} else {
+					assert.NoError(t, err)
+					assert.Equal(t, eventsJSON(t, tc.wantedEvents), repo.note("HEAD"))
+				}
+			})
+		})
+	}
+}
+
+func TestConditionalSetConflictingFlags(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		args := disableFetch([]string{"set", "key", "value", "--if-absent", "--if-equals", "value"})
+		_, err := newTestRepo(t, backend).run(args...)
+
+		assert.Error(t, err)
+	})
+}
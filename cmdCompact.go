@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func addCompactCommandTo(root *cobra.Command) {
+	var (
+		push bool
+	)
+
+	var compactCommand = &cobra.Command{
+		Use:   "compact",
+		Short: "Write a checkpoint holding all key/values",
+		Long: `Write a checkpoint holding all key/values into the note of the commit.
+Replaying events stops at the most recent checkpoint, so older notes no longer need to be read`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
+
+			if globalFlags.Fetch {
+				err = store.Fetch()
+				if err != nil {
+					return err
+				}
+			}
+
+			commitHash, numberOfKeys, err := store.Compact()
+			if err != nil {
+				return err
+			}
+
+			err = store.Prune()
+			if err != nil {
+				return err
+			}
+
+			if push {
+				err = store.Push()
+				if err != nil {
+					return err
+				}
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Checkpoint with %d key(s) written on %s\n", numberOfKeys, commitHash)
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+
+	compactCommand.Flags().BoolVar(&push, "push", false, "Push notes to upstream")
+	root.AddCommand(compactCommand)
+}
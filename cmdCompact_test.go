@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactCommand(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		startEvents := []event.Event{event.TestDataSetFooBar, event.TestDataUnsetKey, event.TestDataSetKeyValue}
+		repo := newTestRepo(t, backend)
+		hash := repo.git("rev-parse", "HEAD")
+		repo.setNote("HEAD", startEvents)
+
+		checkpoint := event.NewCheckpointEvent([]event.SnapshotEntry{
+			{Key: event.TestDataFoo, Value: event.TestDataBar, Commit: hash},
+			{Key: event.TestDataKey, Unset: true, Commit: hash},
+		})
+		wantedEvents := append([]event.Event{*checkpoint}, startEvents...)
+
+		args := disableFetch([]string{"compact"})
+		gotOutput, err := repo.run(args...)
+
+		assert.NoError(t, err)
+		assert.Equal(t, eventsJSON(t, wantedEvents), repo.note("HEAD"))
+		assert.Equal(t, "Checkpoint with 1 key(s) written on "+hash+"\n", gotOutput)
+	})
+}
+
+func TestCheckpointInterval(t *testing.T) {
+	testCases := []struct {
+		name             string
+		interval         string
+		wantedCheckpoint bool
+	}{
+		{
+			name:             "Disabled",
+			interval:         "0",
+			wantedCheckpoint: false,
+		},
+		{
+			name:             "Due",
+			interval:         "1",
+			wantedCheckpoint: true,
+		},
+		{
+			name:             "Not due yet",
+			interval:         "2",
+			wantedCheckpoint: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", []event.Event{event.TestDataSetKeyValue})
+
+				args := disableFetch([]string{"set", "foo", "bar", "--checkpoint-interval", tc.interval})
+				_, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				events, _, err := event.Decode(repo.note("HEAD"))
+				if assert.NoError(t, err) {
+					assert.Equal(t, tc.wantedCheckpoint, events[0].EventType == event.Checkpoint)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/spf13/cobra"
+)
+
+// ValueChange represents the old and new value of a changed key
+type ValueChange struct {
+	Old ginokeva.Value `json:"old"`
+	New ginokeva.Value `json:"new"`
+}
+
+// ValuesDiff represents the differences between two snapshots of values
+type ValuesDiff struct {
+	Added   map[string]ginokeva.Value `json:"added"`
+	Removed map[string]ginokeva.Value `json:"removed"`
+	Changed map[string]ValueChange    `json:"changed"`
+}
+
+func addDiffCommandTo(root *cobra.Command) {
+	var (
+		outputFormat string
+	)
+
+	var diffCommand = &cobra.Command{
+		Use:   "diff [rev-a] [rev-b]",
+		Short: "Show differences between two revisions",
+		Long:  `Show the keys that were added, removed or changed between the snapshots at two revisions`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			revA := args[0]
+			revB := args[1]
+
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
+
+			if globalFlags.Fetch {
+				err = store.Fetch()
+				if err != nil {
+					return err
+				}
+			}
+
+			out, err := getDiffOutput(store.At(revA), store.At(revB), outputFormat)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), out)
+			return nil
+		},
+		Args: cobra.ExactArgs(2),
+	}
+	diffCommand.Flags().StringVarP(&outputFormat, "output", "o", "plain", "Set output format (plain/json/patch)")
+
+	root.AddCommand(diffCommand)
+}
+
+func getDiffOutput(storeA *ginokeva.Store, storeB *ginokeva.Store, outputFormat string) (out string, err error) {
+	valuesA, err := storeA.List()
+	if err != nil {
+		return "", err
+	}
+
+	valuesB, err := storeB.List()
+	if err != nil {
+		return "", err
+	}
+
+	return convertDiffToOutput(diffValues(valuesA, valuesB), outputFormat)
+}
+
+func diffValues(a, b *ginokeva.Values) *ValuesDiff {
+	diff := &ValuesDiff{
+		Added:   map[string]ginokeva.Value{},
+		Removed: map[string]ginokeva.Value{},
+		Changed: map[string]ValueChange{},
+	}
+
+	for k, v := range a.Iterate() {
+		if !b.HasKey(k) {
+			diff.Removed[k] = v
+		} else if b.Get(k) != v {
+			diff.Changed[k] = ValueChange{Old: v, New: b.Get(k)}
+		}
+	}
+
+	for k, v := range b.Iterate() {
+		if !a.HasKey(k) {
+			diff.Added[k] = v
+		}
+	}
+
+	return diff
+}
+
+// Keys returns all keys in the diff, sorted
+func (d ValuesDiff) Keys() []string {
+	keys := []string{}
+	for k := range d.Added {
+		keys = append(keys, k)
+	}
+	for k := range d.Removed {
+		keys = append(keys, k)
+	}
+	for k := range d.Changed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func convertDiffToOutput(diff *ValuesDiff, outputFlag string) (out string, err error) {
+	switch outputFlag {
+
+	case "plain":
+		for _, k := range diff.Keys() {
+			if v, ok := diff.Added[k]; ok {
+				out += fmt.Sprintf("added: %s=%s\n", k, v)
+			} else if v, ok := diff.Removed[k]; ok {
+				out += fmt.Sprintf("removed: %s=%s\n", k, v)
+			} else {
+				c := diff.Changed[k]
+				out += fmt.Sprintf("changed: %s=%s -> %s\n", k, c.Old, c.New)
+			}
+		}
+
+	case "patch":
+		for _, k := range diff.Keys() {
+			if v, ok := diff.Added[k]; ok {
+				out += fmt.Sprintf("+%s=%s\n", k, v)
+			} else if v, ok := diff.Removed[k]; ok {
+				out += fmt.Sprintf("-%s=%s\n", k, v)
+			} else {
+				c := diff.Changed[k]
+				out += fmt.Sprintf("-%s=%s\n+%s=%s\n", k, c.Old, k, c.New)
+			}
+		}
+
+	case "json":
+		var result []byte
+		result, err = json.MarshalIndent(diff, "", "  ")
+		if err == nil {
+			out = fmt.Sprintf("%s\n", result)
+		}
+
+	default:
+		err = &InvalidOutputFormat{}
+	}
+
+	return out, err
+}
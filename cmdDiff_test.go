@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCommand(t *testing.T) {
+	var (
+		valueBaz      = "baz"
+		setFooBaz     = event.Event{EventType: event.Set, Key: event.TestDataFoo, Value: &valueBaz}
+		setAddedBaz   = event.Event{EventType: event.Set, Key: "added", Value: &valueBaz}
+		setRemovedBaz = event.Event{EventType: event.Set, Key: "removed", Value: &valueBaz}
+	)
+
+	// Rev "old" points to the parent of the commit rev "new" points to
+	oldNote := []event.Event{event.TestDataSetFooBar, event.TestDataSetKeyValue, setRemovedBaz}
+	newNote := []event.Event{setFooBaz, setAddedBaz, {EventType: event.Unset, Key: "removed"}}
+
+	testCases := []struct {
+		name       string
+		args       []string
+		wantOutput string
+	}{
+		{
+			name:       "Diff (plain output)",
+			args:       []string{"diff", "old", "new"},
+			wantOutput: "added: added=baz\nchanged: foo=bar -> baz\nremoved: removed=baz\n",
+		},
+		{
+			name:       "Diff (patch output)",
+			args:       []string{"diff", "old", "new", "--output", "patch"},
+			wantOutput: "+added=baz\n-foo=bar\n+foo=baz\n-removed=baz\n",
+		},
+		{
+			name:       "Diff reversed (patch output)",
+			args:       []string{"diff", "new", "old", "--output", "patch"},
+			wantOutput: "-added=baz\n-foo=baz\n+foo=bar\n+removed=baz\n",
+		},
+		{
+			name:       "Diff identical revisions (plain output)",
+			args:       []string{"diff", "new", "new"},
+			wantOutput: "",
+		},
+		{
+			name: "Diff (json output)",
+			args: []string{"diff", "old", "new", "--output", "json"},
+			wantOutput: `{
+  "added": {
+    "added": "baz"
+  },
+  "removed": {
+    "removed": "baz"
+  },
+  "changed": {
+    "foo": {
+      "old": "bar",
+      "new": "baz"
+    }
+  }
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", oldNote)
+				repo.git("tag", "old")
+				repo.commit("New")
+				repo.setNote("HEAD", newNote)
+				repo.git("tag", "new")
+
+				args := disableFetch(tc.args)
+				gotOutput, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantOutput, gotOutput)
+			})
+		})
+	}
+}
+
+func TestDiffInvalidOutputFormat(t *testing.T) {
+	t.Run("InvalidOutputFormat error raised when specifying invalid output format", func(t *testing.T) {
+		_, err := convertDiffToOutput(diffValues(ginokeva.NewValues(), ginokeva.NewValues()), "invalid format")
+		if assert.Error(t, err) {
+			assert.IsType(t, &InvalidOutputFormat{}, err)
+		}
+	})
+}
@@ -1,29 +1,46 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
 	"github.com/spf13/cobra"
 )
 
 func addGetCommandTo(root *cobra.Command) {
+	var (
+		defaultValue string
+		outputFormat string
+	)
+
 	var getCommand = &cobra.Command{
 		Use:   "get [key]",
 		Short: "Get the value of a specific key",
-		Long:  `Get the value of a specific key`,
+		Long: `Get the value of a specific key.
+Exits with code 2 if the key is not found, unless a default value is provided`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			key := args[0]
 
-			gitWrapper := GetGitWrapperFrom(cmd.Context())
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
 
 			if globalFlags.Fetch {
-				err = fetchNotes(gitWrapper)
+				err = store.Fetch()
 				if err != nil {
 					return err
 				}
 			}
 
-			out, err := getValue(gitWrapper, globalFlags.NotesRef, key)
+			lookup, err := store.Lookup(key)
+			if err != nil {
+				return err
+			}
+
+			if !lookup.Exists && cmd.Flags().Changed("default") {
+				lookup.Value = &defaultValue
+			}
+
+			out, err := convertKeyLookupToOutput(lookup, outputFormat)
 			if err != nil {
 				return err
 			}
@@ -33,15 +50,31 @@ func addGetCommandTo(root *cobra.Command) {
 		},
 		Args: cobra.ExactArgs(1),
 	}
+	getCommand.Flags().StringVar(&defaultValue, "default", "", "Value to return if the key is not found")
+	getCommand.Flags().StringVarP(&outputFormat, "output", "o", "plain", "Set output format (plain/json)")
 
 	root.AddCommand(getCommand)
 }
 
-func getValue(gitWrapper GitWrapper, notesRef string, key string) (string, error) {
-	values, err := calculateKeyValues(gitWrapper, notesRef)
-	if err != nil {
-		return "", err
+func convertKeyLookupToOutput(lookup *ginokeva.KeyLookup, outputFlag string) (out string, err error) {
+	switch outputFlag {
+
+	case "plain":
+		if lookup.Value == nil {
+			return "", &ginokeva.KeyNotFound{Key: lookup.Key}
+		}
+		out = *lookup.Value
+
+	case "json":
+		var result []byte
+		result, err = json.MarshalIndent(lookup, "", "  ")
+		if err == nil {
+			out = fmt.Sprintf("%s\n", result)
+		}
+
+	default:
+		err = &InvalidOutputFormat{}
 	}
 
-	return string(values.Get(key)), nil
+	return out, err
 }
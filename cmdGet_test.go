@@ -1,10 +1,11 @@
 package main
 
 import (
-	"context"
+	"strings"
 	"testing"
 
 	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,63 +23,73 @@ func TestGetCommand(t *testing.T) {
 			start:      []event.Event{event.TestDataSetKeyValue},
 			wantOutput: "value",
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			eventsJSON, _ := event.Marshal(&tc.start)
-
-			root := NewRootCommand()
-			ctx := ContextWithGitWrapper(context.Background(), &notesStub{
-				logCommitsImplementation: responseStubArgsNone(simpleLogCommitsResponse),
-				notesListImplementation:  responseStubArgsString(simpleNotesListResponse),
-				notesShowImplementation:  responseStubArgsStringString(eventsJSON),
-			})
-			args := disableFetch(tc.args)
-			gotOutput, err := executeCommandContext(ctx, root, args...)
-			assert.NoError(t, err)
-			assert.Equal(t, tc.wantOutput, gotOutput)
-		})
-	}
-}
-
-func TestGetValue(t *testing.T) {
-	testCases := []struct {
-		name      string
-		key       string
-		depth     uint
-		start     []event.Event
-		wantValue string
-	}{
 		{
-			name:      "Get value of an existing key",
-			key:       "key",
-			depth:     0,
-			start:     []event.Event{event.TestDataSetKeyValue},
-			wantValue: "value",
+			name:       "Get empty value of a key",
+			args:       []string{"get", "empty"},
+			start:      []event.Event{{EventType: event.Set, Key: "empty", Value: &TestDataEmptyString}},
+			wantOutput: "",
 		},
 		{
-			name:      "Get value of a non-existing key",
-			key:       "nonExistingKey",
-			depth:     0,
-			start:     []event.Event{event.TestDataSetKeyValue},
-			wantValue: "",
+			name:       "Get value of a non-existing key with default",
+			args:       []string{"get", "nonExistingKey", "--default", "fallback"},
+			start:      []event.Event{event.TestDataSetKeyValue},
+			wantOutput: "fallback",
+		},
+		{
+			name:       "Get value of an existing key with default",
+			args:       []string{"get", "key", "--default", "fallback"},
+			start:      []event.Event{event.TestDataSetKeyValue},
+			wantOutput: "value",
+		},
+		{
+			name:       "Get value of a key (json output)",
+			args:       []string{"get", "key", "--output", "json"},
+			start:      []event.Event{event.TestDataSetKeyValue},
+			wantOutput: "{\n  \"key\": \"key\",\n  \"exists\": true,\n  \"value\": \"value\",\n  \"commit\": \"HEAD\"\n}\n",
+		},
+		{
+			name:       "Get value of a non-existing key (json output)",
+			args:       []string{"get", "nonExistingKey", "--output", "json"},
+			start:      []event.Event{event.TestDataSetKeyValue},
+			wantOutput: "{\n  \"key\": \"nonExistingKey\",\n  \"exists\": false\n}\n",
+		},
+		{
+			name:       "Get value of a non-existing key with default (json output)",
+			args:       []string{"get", "nonExistingKey", "--output", "json", "--default", ""},
+			start:      []event.Event{event.TestDataSetKeyValue},
+			wantOutput: "{\n  \"key\": \"nonExistingKey\",\n  \"exists\": false,\n  \"value\": \"\"\n}\n",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			eventsJSON, _ := event.Marshal(&[]event.Event{event.TestDataSetKeyValue})
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", tc.start)
 
-			gitWrapper := notesStub{
-				logCommitsImplementation: responseStubArgsNone(simpleLogCommitsResponse),
-				notesListImplementation:  responseStubArgsString(simpleNotesListResponse),
-				notesShowImplementation:  responseStubArgsStringString(eventsJSON),
-			}
-			gotValue, err := getValue(&gitWrapper, TestDataDummyRef, tc.key)
+				args := disableFetch(tc.args)
+				gotOutput, err := repo.run(args...)
 
-			assert.NoError(t, err)
-			assert.Equal(t, tc.wantValue, gotValue)
+				// The commit is only known once the repository is created
+				wantOutput := strings.Replace(tc.wantOutput, `"HEAD"`, `"`+repo.git("rev-parse", "HEAD")+`"`, 1)
+				assert.NoError(t, err)
+				assert.Equal(t, wantOutput, gotOutput)
+			})
 		})
 	}
 }
+
+func TestGetCommandKeyNotFound(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		repo := newTestRepo(t, backend)
+		repo.setNote("HEAD", []event.Event{event.TestDataSetKeyValue})
+
+		args := disableFetch([]string{"get", "nonExistingKey"})
+		_, err := repo.run(args...)
+
+		if assert.Error(t, err) {
+			assert.IsType(t, &ginokeva.KeyNotFound{}, err)
+			assert.Equal(t, exitCodeKeyNotFound, getExitCode(err))
+		}
+	})
+}
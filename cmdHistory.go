@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/spf13/cobra"
+)
+
+func addHistoryCommandTo(root *cobra.Command) {
+	var (
+		outputFormat string
+	)
+
+	var historyCommand = &cobra.Command{
+		Use:   "history [key]",
+		Short: "Show the history of a key",
+		Long:  `Show every commit where the key was set or unset, newest first`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			key := args[0]
+
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
+
+			if globalFlags.Fetch {
+				err = store.Fetch()
+				if err != nil {
+					return err
+				}
+			}
+
+			out, err := getHistoryOutput(store, key, outputFormat)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), out)
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	historyCommand.Flags().StringVarP(&outputFormat, "output", "o", "plain", "Set output format (plain/json)")
+
+	root.AddCommand(historyCommand)
+}
+
+func getHistoryOutput(store *ginokeva.Store, key string, outputFormat string) (out string, err error) {
+	history, err := store.History(key)
+	if err != nil {
+		return "", err
+	}
+
+	return convertHistoryToOutput(history, outputFormat)
+}
+
+func convertHistoryToOutput(history []ginokeva.HistoryEntry, outputFlag string) (out string, err error) {
+	switch outputFlag {
+
+	case "plain":
+		for _, h := range history {
+			switch h.EventType {
+			case event.Set, event.Append, event.Remove:
+				out += fmt.Sprintf("%s %s %s %s (%s)", h.Hash, h.Date, h.EventType, *h.Value, h.Subject)
+			case event.Incr, event.Decr:
+				out += fmt.Sprintf("%s %s %s %d (%s)", h.Hash, h.Date, h.EventType, *h.Delta, h.Subject)
+			default:
+				out += fmt.Sprintf("%s %s %s (%s)", h.Hash, h.Date, h.EventType, h.Subject)
+			}
+			out += describeMetadata(h.Metadata) + "\n"
+		}
+
+	case "json":
+		var result []byte
+		result, err = json.MarshalIndent(history, "", "  ")
+		if err == nil {
+			out = fmt.Sprintf("%s\n", result)
+		}
+
+	default:
+		err = &InvalidOutputFormat{}
+	}
+
+	return out, err
+}
+
+// describeMetadata returns a short description of who changed the key and why, for plain output
+func describeMetadata(m *ginokeva.Metadata) (out string) {
+	if m == nil {
+		return ""
+	}
+
+	if m.User != "" {
+		out += fmt.Sprintf(" by %s", m.User)
+	}
+	if m.Message != "" {
+		out += fmt.Sprintf(": %s", m.Message)
+	}
+
+	return out
+}
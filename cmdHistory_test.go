@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryCommand(t *testing.T) {
+	// Notes of HASH_0 (newest commit) to HASH_2 (oldest commit)
+	notes := [][]event.Event{
+		{event.TestDataUnsetKey},
+		{event.TestDataSetFooBar},
+		{event.TestDataSetKeyOtherValue, event.TestDataSetKeyValue},
+	}
+
+	testCases := []struct {
+		name       string
+		args       []string
+		wantOutput string
+	}{
+		{
+			name: "History of a key (plain output)",
+			args: []string{"history", "key"},
+			wantOutput: "HASH_0 2022-01-04T00:00:00+00:00 unset (Subject 0)\n" +
+				"HASH_2 2022-01-02T00:00:00+00:00 set otherValue (Subject 2)\n" +
+				"HASH_2 2022-01-02T00:00:00+00:00 set value (Subject 2)\n",
+		},
+		{
+			name: "History of a key (json output)",
+			args: []string{"history", "foo", "--output", "json"},
+			wantOutput: `[
+  {
+    "hash": "HASH_1",
+    "date": "2022-01-03T00:00:00+00:00",
+    "subject": "Subject 1",
+    "type": "set",
+    "value": "bar"
+  }
+]
+`,
+		},
+		{
+			name:       "History of a key that was never set (json output)",
+			args:       []string{"history", "unknown", "--output", "json"},
+			wantOutput: "[]\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				hashes := []string{}
+				for i := len(notes) - 1; i >= 0; i-- {
+					hashes = append(hashes, "HASH_"+strconv.Itoa(i), repo.commit("Subject "+strconv.Itoa(i)))
+					repo.setNote("HEAD", notes[i])
+				}
+
+				args := disableFetch(tc.args)
+				gotOutput, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, strings.NewReplacer(hashes...).Replace(tc.wantOutput), gotOutput)
+			})
+		})
+	}
+}
+
+func TestHistoryInvalidOutputFormat(t *testing.T) {
+	t.Run("InvalidOutputFormat error raised when specifying invalid output format", func(t *testing.T) {
+		_, err := convertHistoryToOutput([]ginokeva.HistoryEntry{}, "invalid format")
+		if assert.Error(t, err) {
+			assert.IsType(t, &InvalidOutputFormat{}, err)
+		}
+	})
+}
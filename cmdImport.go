@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// InvalidInputFormat error indicates the specified input format is invalid
+type InvalidInputFormat struct {
+}
+
+func (InvalidInputFormat) Error() string {
+	return "Invalid input format specified"
+}
+
+func addImportCommandTo(root *cobra.Command) {
+	var (
+		inputFormat string
+		onlyChanged bool
+		push        bool
+		sync        bool
+	)
+
+	var importCommand = &cobra.Command{
+		Use:   "import [file|-]",
+		Short: "Import key/values from a file",
+		Long: `Import key/values from a dotenv, JSON or YAML document, read from a file or
+from stdin if no file or - is provided`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var input io.Reader = cmd.InOrStdin()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				input = f
+			}
+
+			keyValues, err := parseDocument(input, inputFormat)
+			if err != nil {
+				return err
+			}
+
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
+
+			if globalFlags.Fetch {
+				err = store.Fetch()
+				if err != nil {
+					return err
+				}
+			}
+
+			err = store.Import(keyValues, onlyChanged, sync)
+			if err != nil {
+				return err
+			}
+
+			err = store.Prune()
+			if err != nil {
+				return err
+			}
+
+			if push {
+				err = store.Push()
+			}
+
+			return err
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	importCommand.Flags().StringVarP(&inputFormat, "format", "f", "env", "Set input format (env/json/yaml)")
+	importCommand.Flags().BoolVar(&onlyChanged, "only-changed", false, "Only set keys whose value differs from the current value")
+	importCommand.Flags().BoolVar(&sync, "sync", false, "Unset keys which are absent from the input")
+	importCommand.Flags().BoolVar(&push, "push", false, "Push notes to upstream")
+	root.AddCommand(importCommand)
+}
+
+func parseDocument(r io.Reader, inputFormat string) (keyValues []ginokeva.KeyValue, err error) {
+	switch inputFormat {
+	case "env":
+		return parseDotenv(r)
+	case "json":
+		return parseJSON(r)
+	case "yaml":
+		return parseYAML(r)
+	default:
+		return nil, &InvalidInputFormat{}
+	}
+}
+
+func parseDotenv(r io.Reader) (keyValues []ginokeva.KeyValue, err error) {
+	keyValues = []ginokeva.KeyValue{}
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		fields := strings.SplitN(line, "=", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected key=value", lineNumber)
+		}
+
+		startLine := lineNumber
+		rawValue := strings.TrimSpace(fields[1])
+		value, err := unquoteDotenvValue(rawValue)
+
+		// A single-quoted value may span multiple lines
+		for err == errUnterminatedQuote && scanner.Scan() {
+			lineNumber++
+			rawValue += "\n" + scanner.Text()
+			value, err = unquoteDotenvValue(rawValue)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", startLine, err)
+		}
+		keyValues = append(keyValues, ginokeva.KeyValue{Key: strings.TrimSpace(fields[0]), Value: value})
+	}
+
+	return keyValues, scanner.Err()
+}
+
+var errUnterminatedQuote = errors.New("unterminated single-quoted value")
+
+func unquoteDotenvValue(s string) (string, error) {
+	if strings.HasPrefix(s, "'") {
+		return unquoteShell(s)
+	}
+
+	if strings.HasPrefix(s, `"`) {
+		return strconv.Unquote(s)
+	}
+
+	return s, nil
+}
+
+// unquoteShell reads a value quoted like a POSIX shell word: single-quoted strings are taken literally, and may be
+// concatenated with backslash-escaped and unquoted characters. This reads values as quoted by list -o env
+func unquoteShell(s string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return "", errUnterminatedQuote
+			}
+			b.WriteString(s[i+1 : i+1+end])
+			i += end + 2
+		case '\\':
+			if i+1 == len(s) {
+				return "", errors.New("value ends with a backslash")
+			}
+			b.WriteByte(s[i+1])
+			i += 2
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+
+	return b.String(), nil
+}
+
+func parseJSON(r io.Reader) (keyValues []ginokeva.KeyValue, err error) {
+	var document map[string]interface{}
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	return convertDocumentToKeyValues(document)
+}
+
+func parseYAML(r io.Reader) (keyValues []ginokeva.KeyValue, err error) {
+	var document map[string]interface{}
+
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(buf.Bytes(), &document); err != nil {
+		return nil, err
+	}
+
+	return convertDocumentToKeyValues(document)
+}
+
+// convertDocumentToKeyValues is the inverse of list --output json/yaml. Nested objects are namespaces, so their
+// keys are flattened into namespaced keys. Keys which aren't valid that way are reported by Import, like any other
+// invalid key. Values are typed by their native type: integers as int, booleans as bool, arrays of strings as list,
+// and other arrays as json. Empty objects can't be a namespace, so these are imported as json as well
+func convertDocumentToKeyValues(document map[string]interface{}) (keyValues []ginokeva.KeyValue, err error) {
+	keyValues = []ginokeva.KeyValue{}
+	err = flattenDocument("", document, &keyValues)
+	return keyValues, err
+}
+
+func flattenDocument(namespace string, document map[string]interface{}, keyValues *[]ginokeva.KeyValue) error {
+	keys := []string{}
+	for k := range document {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := namespace + k
+
+		if v, ok := document[k].(map[string]interface{}); ok && len(v) > 0 {
+			err := flattenDocument(key+event.NamespaceSeparator, v, keyValues)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		kv, err := convertDocumentValue(key, document[k])
+		if err != nil {
+			return err
+		}
+		*keyValues = append(*keyValues, kv)
+	}
+
+	return nil
+}
+
+func convertDocumentValue(key string, v interface{}) (ginokeva.KeyValue, error) {
+	kv := ginokeva.KeyValue{Key: key}
+
+	switch v := v.(type) {
+	case nil:
+		return kv, fmt.Errorf("value of key %q is null", key)
+	case string:
+		kv.Value = v
+	case bool:
+		kv.Value, kv.ValueType = strconv.FormatBool(v), ginokeva.Bool
+	case int:
+		kv.Value, kv.ValueType = strconv.Itoa(v), ginokeva.Int
+	case json.Number:
+		kv.Value = v.String()
+		if _, err := v.Int64(); err == nil {
+			kv.ValueType = ginokeva.Int
+		}
+	case []interface{}:
+		value, err := json.Marshal(v)
+		if err != nil {
+			return kv, fmt.Errorf("value of key %q: %v", key, err)
+		}
+		kv.Value, kv.ValueType = string(value), ginokeva.JSON
+		if isStringList(v) {
+			kv.ValueType = ginokeva.List
+		}
+	case map[string]interface{}:
+		value, err := json.Marshal(v)
+		if err != nil {
+			return kv, fmt.Errorf("value of key %q: %v", key, err)
+		}
+		kv.Value, kv.ValueType = string(value), ginokeva.JSON
+	default:
+		kv.Value = fmt.Sprint(v)
+	}
+
+	return kv, nil
+}
+
+func isStringList(items []interface{}) bool {
+	for _, item := range items {
+		if _, ok := item.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
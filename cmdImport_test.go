@@ -0,0 +1,270 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportCommand(t *testing.T) {
+	var (
+		valueBaz  = "baz"
+		setFooBaz = event.Event{EventType: event.Set, Key: event.TestDataFoo, Value: &valueBaz}
+		unsetFoo  = event.Event{EventType: event.Unset, Key: event.TestDataFoo}
+	)
+
+	testCases := []struct {
+		name         string
+		args         []string
+		input        string
+		startEvents  []event.Event
+		wantedEvents []event.Event
+	}{
+		{
+			name:         "Import dotenv (default)",
+			args:         []string{"import"},
+			input:        "# Comment\nkey=value\nexport foo=\"bar\"\n",
+			startEvents:  []event.Event{},
+			wantedEvents: []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+		},
+		{
+			name:         "Import json",
+			args:         []string{"import", "--format", "json", "-"},
+			input:        `{"key": "value", "foo": "bar"}`,
+			startEvents:  []event.Event{},
+			wantedEvents: []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+		},
+		{
+			name:         "Import yaml",
+			args:         []string{"import", "--format", "yaml"},
+			input:        "key: value\nfoo: bar\n",
+			startEvents:  []event.Event{},
+			wantedEvents: []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+		},
+		{
+			name:         "Import only changed values",
+			args:         []string{"import", "--only-changed"},
+			input:        "key=value\nfoo=baz\n",
+			startEvents:  []event.Event{event.TestDataSetFooBar, event.TestDataSetKeyValue},
+			wantedEvents: []event.Event{setFooBaz, event.TestDataSetFooBar, event.TestDataSetKeyValue},
+		},
+		{
+			name:         "Import with sync unsets absent keys",
+			args:         []string{"import", "--sync", "--only-changed"},
+			input:        "key=value\n",
+			startEvents:  []event.Event{event.TestDataSetFooBar, event.TestDataSetKeyValue},
+			wantedEvents: []event.Event{unsetFoo, event.TestDataSetFooBar, event.TestDataSetKeyValue},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", tc.startEvents)
+
+				args := disableFetch(tc.args)
+				_, err := repo.runWithInput(tc.input, args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, eventsJSON(t, tc.wantedEvents), repo.note("HEAD"))
+			})
+		})
+	}
+}
+
+func TestImportNothingChanged(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		startEvents := []event.Event{event.TestDataSetKeyValue}
+		repo := newTestRepo(t, backend)
+		repo.setNote("HEAD", startEvents)
+
+		args := disableFetch([]string{"import", "--only-changed"})
+		_, err := repo.runWithInput("key=value\n", args...)
+
+		assert.NoError(t, err)
+		assert.Equal(t, eventsJSON(t, startEvents), repo.note("HEAD"), "No note should be written if nothing changed")
+	})
+}
+
+func TestImportNestedInvalidKey(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		repo := newTestRepo(t, backend)
+
+		args := disableFetch([]string{"import", "--format", "json"})
+		_, err := repo.runWithInput(`{"foo": "bar", "config": {"max retries": 3}}`, args...)
+
+		var invalidKeys *ginokeva.InvalidKeys
+		if assert.ErrorAs(t, err, &invalidKeys) {
+			assert.Contains(t, invalidKeys.Errors, "config/max retries")
+			assert.Len(t, invalidKeys.Errors, 1)
+		}
+		assert.Equal(t, "", repo.note("HEAD"))
+	})
+}
+
+func TestParseDocument(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputFormat string
+		input       string
+		wanted      []ginokeva.KeyValue
+		wantErr     bool
+	}{
+		{
+			name:        "dotenv with quoted values",
+			inputFormat: "env",
+			input:       "single='it is \"quoted\"'\ndouble=\"line\\nbreak\"\nempty=\n",
+			wanted: []ginokeva.KeyValue{
+				{Key: "single", Value: "it is \"quoted\""},
+				{Key: "double", Value: "line\nbreak"},
+				{Key: "empty", Value: ""},
+			},
+		},
+		{
+			name:        "dotenv with concatenated single-quoted values",
+			inputFormat: "env",
+			input:       "export quote='it'\\''s'\nmixed='a'b' c'\\ d\nmultiline='first\nsecond'\n",
+			wanted: []ginokeva.KeyValue{
+				{Key: "quote", Value: "it's"},
+				{Key: "mixed", Value: "ab c d"},
+				{Key: "multiline", Value: "first\nsecond"},
+			},
+		},
+		{
+			name:        "dotenv with unterminated single-quoted value",
+			inputFormat: "env",
+			input:       "foo='bar\n",
+			wantErr:     true,
+		},
+		{
+			name:        "dotenv without separator",
+			inputFormat: "env",
+			input:       "foo\n",
+			wantErr:     true,
+		},
+		{
+			name:        "json with typed scalars",
+			inputFormat: "json",
+			input:       `{"int": 12, "float": 3.14, "bool": true}`,
+			wanted: []ginokeva.KeyValue{
+				{Key: "bool", Value: "true", ValueType: ginokeva.Bool},
+				{Key: "float", Value: "3.14"},
+				{Key: "int", Value: "12", ValueType: ginokeva.Int},
+			},
+		},
+		{
+			name:        "json with nested object is flattened into namespaced keys",
+			inputFormat: "json",
+			input:       `{"svc": {"foo": {"version": "1.0"}, "bar": "baz"}}`,
+			wanted: []ginokeva.KeyValue{
+				{Key: "svc/bar", Value: "baz"},
+				{Key: "svc/foo/version", Value: "1.0"},
+			},
+		},
+		{
+			name:        "json with arrays and an empty object",
+			inputFormat: "json",
+			input:       `{"regions": ["eu", "us"], "matrix": [1, [2]], "empty": {}}`,
+			wanted: []ginokeva.KeyValue{
+				{Key: "empty", Value: `{}`, ValueType: ginokeva.JSON},
+				{Key: "matrix", Value: `[1,[2]]`, ValueType: ginokeva.JSON},
+				{Key: "regions", Value: `["eu","us"]`, ValueType: ginokeva.List},
+			},
+		},
+		{
+			name:        "json with nested object with invalid key names is flattened as well",
+			inputFormat: "json",
+			input:       `{"config": {"max retries": 3}}`,
+			wanted: []ginokeva.KeyValue{
+				{Key: "config/max retries", Value: "3", ValueType: ginokeva.Int},
+			},
+		},
+		{
+			name:        "json with null",
+			inputFormat: "json",
+			input:       `{"foo": null}`,
+			wantErr:     true,
+		},
+		{
+			name:        "yaml with typed scalars, namespaces and lists",
+			inputFormat: "yaml",
+			input:       "int: 12\nbool: true\nsvc:\n  regions:\n    - eu\n",
+			wanted: []ginokeva.KeyValue{
+				{Key: "bool", Value: "true", ValueType: ginokeva.Bool},
+				{Key: "int", Value: "12", ValueType: ginokeva.Int},
+				{Key: "svc/regions", Value: `["eu"]`, ValueType: ginokeva.List},
+			},
+		},
+		{
+			name:        "invalid input format",
+			inputFormat: "xml",
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseDocument(strings.NewReader(tc.input), tc.inputFormat)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wanted, got)
+			}
+		})
+	}
+}
+
+func TestImportListEnvRoundTrip(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		source := newTestRepo(t, backend)
+		for _, kv := range [][]string{{"quote", "it's"}, {"multiline", "first\nsecond"}, {"plain", "value"}} {
+			_, err := source.run("set", kv[0], kv[1], "--fetch=false")
+			assert.NoError(t, err)
+		}
+
+		env, err := source.run("list", "--output", "env", "--fetch=false")
+		assert.NoError(t, err)
+
+		target := newTestRepo(t, backend)
+		_, err = target.runWithInput(env, "import", "--fetch=false")
+		assert.NoError(t, err)
+
+		wanted, _ := source.run("list", "--output", "json", "--fetch=false")
+		got, err := target.run("list", "--output", "json", "--fetch=false")
+		assert.NoError(t, err)
+		assert.Equal(t, wanted, got)
+	})
+}
+
+func TestImportListJSONRoundTrip(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		source := newTestRepo(t, backend)
+		for _, args := range [][]string{
+			{"set", "svc/foo/version", "1.0"},
+			{"set", "svc/foo/replicas", "3", "--type", "int"},
+			{"set", "regions", "eu,us", "--type", "list"},
+			{"set", "matrix", `[1,{"max retries":3}]`, "--type", "json"},
+			{"set", "enabled", "true", "--type", "bool"},
+		} {
+			_, err := source.run(append(args, "--fetch=false")...)
+			assert.NoError(t, err)
+		}
+
+		wanted, err := source.run("list", "--output", "json", "--fetch=false")
+		assert.NoError(t, err)
+
+		target := newTestRepo(t, backend)
+		_, err = target.runWithInput(wanted, "import", "--format", "json", "--fetch=false")
+		assert.NoError(t, err)
+
+		got, err := target.run("list", "--output", "json", "--fetch=false")
+		assert.NoError(t, err)
+		assert.Equal(t, wanted, got)
+	})
+}
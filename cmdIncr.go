@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/spf13/cobra"
+)
+
+func addIncrCommandTo(root *cobra.Command) {
+	root.AddCommand(newCounterCommand(event.Incr, "incr", "Increment the integer value of a key"))
+}
+
+func addDecrCommandTo(root *cobra.Command) {
+	root.AddCommand(newCounterCommand(event.Decr, "decr", "Decrement the integer value of a key"))
+}
+
+func newCounterCommand(eventType event.Type, use string, short string) *cobra.Command {
+	var (
+		push bool
+	)
+
+	var counterCommand = &cobra.Command{
+		Use:   fmt.Sprintf("%s [key] [delta]", use),
+		Short: short,
+		Long: short + ` by delta (default 1) and print the resulting value.
+A key which isn't set yet starts at 0`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			key := args[0]
+
+			var delta int64 = 1
+			if len(args) == 2 {
+				delta, err = strconv.ParseInt(args[1], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid delta: %v", args[1])
+				}
+			}
+
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
+
+			if globalFlags.Fetch {
+				err = store.Fetch()
+				if err != nil {
+					return err
+				}
+			}
+
+			var value string
+			if eventType == event.Incr {
+				value, err = store.Incr(key, delta)
+			} else {
+				value, err = store.Decr(key, delta)
+			}
+			if err != nil {
+				return err
+			}
+
+			err = store.Prune()
+			if err != nil {
+				return err
+			}
+
+			if push {
+				err = store.Push()
+				if err != nil {
+					return err
+				}
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+		Args: cobra.RangeArgs(1, 2),
+	}
+
+	counterCommand.Flags().BoolVar(&push, "push", false, "Push notes to upstream")
+	return counterCommand
+}
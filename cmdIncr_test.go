@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterCommand(t *testing.T) {
+	delta1 := int64(1)
+
+	testCases := []struct {
+		name         string
+		args         []string
+		startEvents  []event.Event
+		wantedEvents []event.Event
+		wantedOutput string
+	}{
+		{
+			name:         "Incr without prior value",
+			startEvents:  []event.Event{},
+			args:         []string{"incr", "counter"},
+			wantedEvents: []event.Event{{EventType: event.Incr, Key: event.TestDataCounter, Delta: &delta1}},
+			wantedOutput: "1\n",
+		},
+		{
+			name:         "Incr counter=12 by 5",
+			startEvents:  []event.Event{event.TestDataSetCounterInt12},
+			args:         []string{"incr", "counter", "5"},
+			wantedEvents: []event.Event{event.TestDataIncrCounter5, event.TestDataSetCounterInt12},
+			wantedOutput: "17\n",
+		},
+		{
+			name:         "Decr counter=12 by 5",
+			startEvents:  []event.Event{event.TestDataSetCounterInt12},
+			args:         []string{"decr", "counter", "5"},
+			wantedEvents: []event.Event{event.TestDataDecrCounter5, event.TestDataSetCounterInt12},
+			wantedOutput: "7\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", tc.startEvents)
+
+				args := disableFetch(tc.args)
+				gotOutput, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, eventsJSON(t, tc.wantedEvents), repo.note("HEAD"))
+				assert.Equal(t, tc.wantedOutput, gotOutput)
+			})
+		})
+	}
+}
+
+func TestCounterCommandNotAnInteger(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		startEvents := []event.Event{event.TestDataSetKeyValue}
+		repo := newTestRepo(t, backend)
+		repo.setNote("HEAD", startEvents)
+
+		args := disableFetch([]string{"incr", "key"})
+		_, err := repo.run(args...)
+
+		if assert.Error(t, err) {
+			assert.IsType(t, &ginokeva.NotAnInteger{}, err)
+		}
+		assert.Equal(t, eventsJSON(t, startEvents), repo.note("HEAD"), "Note should be unchanged")
+	})
+}
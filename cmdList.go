@@ -1,10 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
+	"text/template"
 
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	templateOutputPrefix = "template="
 )
 
 // InvalidOutputFormat error indicates the specified output format is invalid
@@ -15,9 +27,45 @@ func (InvalidOutputFormat) Error() string {
 	return "Invalid output format specified"
 }
 
+// InvalidSortOrder error indicates the specified sort order is invalid
+type InvalidSortOrder struct {
+}
+
+func (InvalidSortOrder) Error() string {
+	return "Invalid sort order specified"
+}
+
+// EnvNameConflict error indicates multiple keys map to the same shell variable name in env output
+type EnvNameConflict struct {
+	Name string
+	Keys []string
+}
+
+func (e EnvNameConflict) Error() string {
+	return fmt.Sprintf("Keys %v all map to shell variable %v, cannot output as env", strings.Join(e.Keys, ", "), e.Name)
+}
+
+// TemplateData is the data available to templates rendered by the list command
+type TemplateData struct {
+	Keys   []string
+	Values map[string]ginokeva.Value
+	Commit *ginokeva.CommitInfo
+}
+
 func addListCommandTo(root *cobra.Command) {
 	var (
 		outputFormat string
+		sortOrder    string
+		templateFile string
+		namespace    string
+
+		prefixes    []string
+		globs       []string
+		regexes     []string
+		excludes    []string
+		stripPrefix bool
+
+		includeExpired bool
 	)
 
 	var listCommand = &cobra.Command{
@@ -25,16 +73,36 @@ func addListCommandTo(root *cobra.Command) {
 		Short: "List",
 		Long:  `List all of the keys and values currently stored`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			gitWrapper := GetGitWrapperFrom(cmd.Context())
+			if templateFile != "" {
+				text, err := os.ReadFile(templateFile)
+				if err != nil {
+					return err
+				}
+				outputFormat = templateOutputPrefix + string(text)
+			}
+
+			if namespace != "" {
+				err = event.ValidateNamespace(namespace)
+				if err != nil {
+					return err
+				}
+			}
+
+			filter, err := ginokeva.NewKeyFilter(prefixes, globs, regexes, excludes, stripPrefix)
+			if err != nil {
+				return err
+			}
+
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
 
 			if globalFlags.Fetch {
-				err = fetchNotes(gitWrapper)
+				err = store.Fetch()
 				if err != nil {
 					return err
 				}
 			}
 
-			out, err := getListOutput(gitWrapper, globalFlags.NotesRef, outputFormat)
+			out, err := getListOutput(store, outputFormat, sortOrder, namespace, filter, includeExpired)
 			if err != nil {
 				return err
 			}
@@ -44,35 +112,92 @@ func addListCommandTo(root *cobra.Command) {
 		},
 		Args: cobra.NoArgs,
 	}
-	listCommand.Flags().StringVarP(&outputFormat, "output", "o", "plain", "Set output format (plain/json)")
+	listCommand.Flags().StringVarP(&outputFormat, "output", "o", "plain", "Set output format (plain/json/env/yaml/toml/csv/template=...)")
+	listCommand.Flags().StringVar(&sortOrder, "sort", "key", "Set sort order (key/modified)")
+	listCommand.Flags().StringVar(&templateFile, "template-file", "", "Render output using the Go template in this file")
+	listCommand.Flags().StringVar(&namespace, "namespace", "", "Only list keys inside this namespace, relative to the namespace")
+	listCommand.Flags().StringSliceVar(&prefixes, "prefix", nil, "Only list keys starting with this prefix")
+	listCommand.Flags().StringSliceVar(&globs, "match", nil, "Only list keys matching this glob pattern")
+	listCommand.Flags().StringSliceVar(&regexes, "regex", nil, "Only list keys matching this regular expression")
+	listCommand.Flags().StringSliceVar(&excludes, "exclude", nil, "Don't list keys matching this glob pattern")
+	listCommand.Flags().BoolVar(&stripPrefix, "strip-prefix", false, "Strip the prefix specified with --prefix from the listed keys")
+	listCommand.Flags().BoolVar(&includeExpired, "include-expired", false, "Also list values which are expired")
 
 	root.AddCommand(listCommand)
 }
 
-func getListOutput(gitWrapper GitWrapper, notesRef string, outputFormat string) (out string, err error) {
-	values, err := calculateKeyValues(gitWrapper, notesRef)
+func getListOutput(store *ginokeva.Store, outputFormat string, sortOrder string, namespace string, filter *ginokeva.KeyFilter, includeExpired bool) (out string, err error) {
+	var values *ginokeva.Values
+	if includeExpired {
+		values, err = store.ListIncludingExpired()
+	} else {
+		values, err = store.List()
+	}
 	if err != nil {
 		return "", err
 	}
 
+	if namespace != "" {
+		values = values.Namespace(namespace)
+	}
+
+	if filter != nil {
+		values, err = filter.Apply(values)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch sortOrder {
+	case "key":
+		values.SetSortOrder(ginokeva.SortByKey)
+	case "modified":
+		values.SetSortOrder(ginokeva.SortByLastModified)
+	default:
+		return "", &InvalidSortOrder{}
+	}
+
+	if strings.HasPrefix(outputFormat, templateOutputPrefix) {
+		commit, err := store.Commit()
+		if err != nil {
+			return "", err
+		}
+
+		return renderTemplate(strings.TrimPrefix(outputFormat, templateOutputPrefix), &TemplateData{
+			Keys:   values.Keys(),
+			Values: values.Iterate(),
+			Commit: commit,
+		})
+	}
+
 	return convertValuesToOutput(values, outputFormat)
 }
 
-func convertValuesToOutput(values *Values, outputFlag string) (out string, err error) {
+func convertValuesToOutput(values *ginokeva.Values, outputFlag string) (out string, err error) {
 	switch outputFlag {
 
 	case "plain":
-		if values.Count() == 0 {
-			out = ""
-		} else {
-			for k, v := range values.Iterate() {
-				out += fmt.Sprintf("%s=%s\n", k, v)
-			}
+		for _, k := range values.Keys() {
+			out += fmt.Sprintf("%s=%s\n", k, values.Get(k))
 		}
 
 	case "json":
 		out, err = marshalJSON(values)
 
+	case "env":
+		out, err = marshalEnv(values)
+
+	case "yaml":
+		out, err = marshalYAML(values)
+
+	case "toml":
+		for _, k := range values.Keys() {
+			out += fmt.Sprintf("%s = %s\n", tomlKey(k), quoteTOML(string(values.Get(k))))
+		}
+
+	case "csv":
+		out, err = marshalCSV(values)
+
 	default:
 		err = &InvalidOutputFormat{}
 	}
@@ -80,11 +205,108 @@ func convertValuesToOutput(values *Values, outputFlag string) (out string, err e
 	return out, err
 }
 
-func marshalJSON(values *Values) (string, error) {
-	result, err := json.MarshalIndent(values.Iterate(), "", "  ")
+// marshalEnv returns export lines which can be safely eval'ed by a POSIX shell. Keys are converted to valid shell
+// variable names, which must be unique
+func marshalEnv(values *ginokeva.Values) (string, error) {
+	keys := map[string][]string{}
+	for _, k := range values.Keys() {
+		name := envName(k)
+		keys[name] = append(keys[name], k)
+	}
+
+	out := ""
+	for _, k := range values.Keys() {
+		name := envName(k)
+		if len(keys[name]) > 1 {
+			return "", &EnvNameConflict{Name: name, Keys: keys[name]}
+		}
+		out += fmt.Sprintf("export %s=%s\n", name, quoteShell(string(values.Get(k))))
+	}
+
+	return out, nil
+}
+
+// envName returns k as a valid shell variable name. Keys may contain dashes and namespace separators, which aren't
+// allowed in shell variable names, so these are replaced by underscores
+func envName(k string) string {
+	return strings.NewReplacer("-", "_", event.NamespaceSeparator, "_").Replace(k)
+}
+
+// quoteShell single-quotes s so it can be safely eval'ed by a POSIX shell
+func quoteShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quoteTOML returns s as a TOML basic string
+func quoteTOML(s string) string {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s) // Encoding a string cannot fail
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// tomlKey returns k as a TOML bare key if possible, or a quoted key otherwise
+func tomlKey(k string) string {
+	if strings.Contains(k, event.NamespaceSeparator) {
+		return quoteTOML(k)
+	}
+
+	return k
+}
+
+func marshalJSON(values *ginokeva.Values) (string, error) {
+	tree, err := values.Tree()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := json.MarshalIndent(tree, "", "  ")
 	if err != nil {
 		return "", err
 	}
 
 	return fmt.Sprintf("%s\n", result), nil
 }
+
+func marshalYAML(values *ginokeva.Values) (string, error) {
+	if values.Count() == 0 {
+		return "{}\n", nil
+	}
+
+	result, err := yaml.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+func marshalCSV(values *ginokeva.Values) (string, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+
+	w.Write([]string{"key", "value"})
+	for _, k := range values.Keys() {
+		w.Write([]string{k, string(values.Get(k))})
+	}
+	w.Flush()
+
+	return buf.String(), w.Error()
+}
+
+func renderTemplate(text string, data *TemplateData) (string, error) {
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	err = tmpl.Execute(buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -1,10 +1,11 @@
 package main
 
 import (
-	"context"
+	"strings"
 	"testing"
 
 	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,24 +31,62 @@ func TestListCommand(t *testing.T) {
 			start:      td,
 			wantOutput: "{\n  \"key\": \"value\"\n}\n",
 		},
+		{
+			name:       "List all notes (env output)",
+			args:       []string{"list", "--output", "env"},
+			start:      []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+			wantOutput: "export foo='bar'\nexport key='value'\n",
+		},
+		{
+			name:       "List all notes (yaml output)",
+			args:       []string{"list", "--output", "yaml"},
+			start:      []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+			wantOutput: "foo: bar\nkey: value\n",
+		},
+		{
+			name:       "List all notes (toml output)",
+			args:       []string{"list", "--output", "toml"},
+			start:      []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+			wantOutput: "foo = \"bar\"\nkey = \"value\"\n",
+		},
+		{
+			name:       "List all notes (csv output)",
+			args:       []string{"list", "--output", "csv"},
+			start:      []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+			wantOutput: "key,value\nfoo,bar\nkey,value\n",
+		},
+		{
+			name:       "List filtered notes with prefix stripped (json output)",
+			args:       []string{"list", "--output", "json", "--prefix", "k", "--strip-prefix"},
+			start:      []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+			wantOutput: "{\n  \"ey\": \"value\"\n}\n",
+		},
+		{
+			name:       "List notes excluding a key (plain output)",
+			args:       []string{"list", "--exclude", "k*"},
+			start:      []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+			wantOutput: "foo=bar\n",
+		},
+		{
+			name:       "List all notes (template output)",
+			args:       []string{"list", "--output", "template={{.Commit.Hash}}{{range $k, $v := .Values}} {{$k}}:{{$v}}{{end}}"},
+			start:      []event.Event{event.TestDataSetKeyValue, event.TestDataSetFooBar},
+			wantOutput: "HEAD foo:bar key:value",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			eventsJSON, _ := event.Marshal(&tc.start)
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", tc.start)
 
-			root := NewRootCommand()
-			ctx := ContextWithGitWrapper(context.Background(), &notesStub{
-				logCommitsImplementation: responseStubArgsNone(simpleLogCommitsResponse),
-				notesListImplementation:  responseStubArgsString(simpleNotesListResponse),
-				notesShowImplementation:  responseStubArgsStringString(eventsJSON),
-			})
-
-			args := disableFetch(tc.args)
-			gotOutput, err := executeCommandContext(ctx, root, args...)
+				args := disableFetch(tc.args)
+				gotOutput, err := repo.run(args...)
 
-			assert.NoError(t, err)
-			assert.Equal(t, tc.wantOutput, gotOutput)
+				assert.NoError(t, err)
+				assert.Equal(t, strings.ReplaceAll(tc.wantOutput, "HEAD", repo.git("rev-parse", "HEAD")), gotOutput)
+			})
 		})
 	}
 }
@@ -85,36 +124,452 @@ func TestGetListOutputTestDataEmpty(t *testing.T) {
 			start:        td,
 			wantText:     "{}\n",
 		},
+		{
+			name:         "Empty note (env)",
+			outputFormat: "env",
+			start:        td,
+			wantText:     TestDataEmptyString,
+		},
+		{
+			name:         "Empty note (yaml)",
+			outputFormat: "yaml",
+			start:        td,
+			wantText:     "{}\n",
+		},
+		{
+			name:         "Empty note (toml)",
+			outputFormat: "toml",
+			start:        td,
+			wantText:     TestDataEmptyString,
+		},
+		{
+			name:         "Empty note (csv)",
+			outputFormat: "csv",
+			start:        td,
+			wantText:     "key,value\n",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			eventsJSON, _ := event.Marshal(&tc.start)
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", tc.start)
 
-			gitWrapper := notesStub{
-				logCommitsImplementation: dummyStubArgsNone,
-				notesListImplementation:  dummyStubArgsString,
-				notesShowImplementation:  responseStubArgsStringString(eventsJSON),
-			}
-			gotOutput, err := getListOutput(&gitWrapper, TestDataDummyRef, tc.outputFormat)
+				gotOutput, err := getListOutput(repo.store(), tc.outputFormat, "key", "", nil, false)
 
-			assert.NoError(t, err)
-			assert.Equal(t, tc.wantText, gotOutput)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantText, gotOutput)
+			})
 		})
 	}
 }
 
 func TestInvalidOutputFormat(t *testing.T) {
-	t.Run("InvalidOutputFormat error raised when specifying invalid output format", func(t *testing.T) {
-		gitWrapper := notesStub{
-			logCommitsImplementation: dummyStubArgsNone,
-			notesListImplementation:  dummyStubArgsString,
-			notesShowImplementation:  dummyStubArgsStringString,
+	forEachBackend(t, func(t *testing.T, backend string) {
+		_, err := getListOutput(newTestRepo(t, backend).store(), "invalid format", "key", "", nil, false)
+		if assert.Error(t, err) {
+			assert.IsType(t, &InvalidOutputFormat{}, err)
+		}
+	})
+}
+
+func TestListAtRevision(t *testing.T) {
+	testCases := []struct {
+		name       string
+		args       []string
+		wantOutput string
+	}{
+		{
+			name:       "List at HEAD (default)",
+			args:       []string{"list"},
+			wantOutput: "foo=bar\nkey=value\n",
+		},
+		{
+			name:       "List at other revision",
+			args:       atRev([]string{"list"}, "origin/release"),
+			wantOutput: "key=value\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", []event.Event{event.TestDataSetKeyValue})
+				repo.git("update-ref", "refs/remotes/origin/release", "HEAD")
+				repo.commit("Next")
+				repo.setNote("HEAD", []event.Event{event.TestDataSetFooBar})
+
+				args := disableFetch(tc.args)
+				gotOutput, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantOutput, gotOutput)
+			})
+		})
+	}
+}
+
+func TestEnvOutput(t *testing.T) {
+	testCases := []struct {
+		name       string
+		keyValues  map[string]string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			name:       "Dashes and namespace separators are replaced by underscores",
+			keyValues:  map[string]string{"foo-bar": "1", "svc/foo/version": "2"},
+			wantOutput: "export foo_bar='1'\nexport svc_foo_version='2'\n",
+		},
+		{
+			name:      "Keys mapping to the same shell variable",
+			keyValues: map[string]string{"foo-bar": "1", "foo_bar": "2"},
+			wantErr:   &EnvNameConflict{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values := ginokeva.NewValues()
+			for k, v := range tc.keyValues {
+				values.Add(k, ginokeva.Value(v))
+			}
+
+			gotOutput, err := convertValuesToOutput(values, "env")
+
+			if tc.wantErr != nil {
+				assert.IsType(t, tc.wantErr, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantOutput, gotOutput)
+			}
+		})
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     string
+		wantShell string
+		wantTOML  string
+	}{
+		{
+			name:      "Simple value",
+			input:     "1.0.0",
+			wantShell: "'1.0.0'",
+			wantTOML:  `"1.0.0"`,
+		},
+		{
+			name:      "Value with quotes and special characters",
+			input:     `it's "$HOME" & <more>`,
+			wantShell: `'it'\''s "$HOME" & <more>'`,
+			wantTOML:  `"it's \"$HOME\" & <more>"`,
+		},
+		{
+			name:      "Value with newline",
+			input:     "foo\nbar",
+			wantShell: "'foo\nbar'",
+			wantTOML:  `"foo\nbar"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantShell, quoteShell(tc.input))
+			assert.Equal(t, tc.wantTOML, quoteTOML(tc.input))
+		})
+	}
+}
+
+func TestListOutputIsSorted(t *testing.T) {
+	var (
+		valueTrue = "true"
+		value12   = "12"
+		notes     = [][]event.Event{
+			{event.TestDataSetKeyValue, {EventType: event.Set, Key: "Zulu", Value: &valueTrue}},
+			{{EventType: event.Set, Key: "alpha", Value: &value12}, event.TestDataSetFooBar},
 		}
+	)
 
-		_, err := getListOutput(&gitWrapper, TestDataDummyRef, "invalid format")
+	testCases := []struct {
+		name       string
+		args       []string
+		wantOutput string
+	}{
+		{
+			name:       "plain output sorted by key (default)",
+			args:       []string{"list"},
+			wantOutput: "Zulu=true\nalpha=12\nfoo=bar\nkey=value\n",
+		},
+		{
+			name:       "plain output sorted by last modification",
+			args:       []string{"list", "--sort", "modified"},
+			wantOutput: "key=value\nZulu=true\nalpha=12\nfoo=bar\n",
+		},
+		{
+			name:       "json output sorted by key",
+			args:       []string{"list", "--output", "json"},
+			wantOutput: "{\n  \"Zulu\": \"true\",\n  \"alpha\": \"12\",\n  \"foo\": \"bar\",\n  \"key\": \"value\"\n}\n",
+		},
+		{
+			name:       "json output sorted by last modification",
+			args:       []string{"list", "--output", "json", "--sort", "modified"},
+			wantOutput: "{\n  \"key\": \"value\",\n  \"Zulu\": \"true\",\n  \"alpha\": \"12\",\n  \"foo\": \"bar\"\n}\n",
+		},
+		{
+			name:       "yaml output sorted by key",
+			args:       []string{"list", "--output", "yaml"},
+			wantOutput: "Zulu: \"true\"\nalpha: \"12\"\nfoo: bar\nkey: value\n",
+		},
+		{
+			name:       "yaml output sorted by last modification",
+			args:       []string{"list", "--output", "yaml", "--sort", "modified"},
+			wantOutput: "key: value\nZulu: \"true\"\nalpha: \"12\"\nfoo: bar\n",
+		},
+		{
+			name:       "env output sorted by last modification",
+			args:       []string{"list", "--output", "env", "--sort", "modified"},
+			wantOutput: "export key='value'\nexport Zulu='true'\nexport alpha='12'\nexport foo='bar'\n",
+		},
+		{
+			name:       "toml output sorted by key",
+			args:       []string{"list", "--output", "toml"},
+			wantOutput: "Zulu = \"true\"\nalpha = \"12\"\nfoo = \"bar\"\nkey = \"value\"\n",
+		},
+		{
+			name:       "csv output sorted by last modification",
+			args:       []string{"list", "--output", "csv", "--sort", "modified"},
+			wantOutput: "key,value\nkey,value\nZulu,true\nalpha,12\nfoo,bar\n",
+		},
+		{
+			name:       "template output using sorted keys",
+			args:       []string{"list", "--output", "template={{range .Keys}}{{.}} {{end}}", "--sort", "modified"},
+			wantOutput: "key Zulu alpha foo ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", notes[1])
+				repo.commit("Next")
+				repo.setNote("HEAD", notes[0])
+
+				// Run repeatedly, since map iteration order is randomized
+				for i := 0; i < 10; i++ {
+					args := disableFetch(tc.args)
+					gotOutput, err := repo.run(args...)
+
+					assert.NoError(t, err)
+					assert.Equal(t, tc.wantOutput, gotOutput)
+				}
+			})
+		})
+	}
+}
+
+func TestInvalidSortOrder(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		_, err := getListOutput(newTestRepo(t, backend).store(), "plain", "invalid order", "", nil, false)
 		if assert.Error(t, err) {
-			assert.IsType(t, &InvalidOutputFormat{}, err)
+			assert.IsType(t, &InvalidSortOrder{}, err)
 		}
 	})
 }
+
+func TestListNamespaces(t *testing.T) {
+	var (
+		value1    = "1.0.0"
+		value2    = "2.0.0"
+		namespace = []event.Event{
+			{EventType: event.Set, Key: "service/foo/version", Value: &value1},
+			{EventType: event.Set, Key: "service/bar/version", Value: &value2},
+			{EventType: event.Set, Key: "service/foo/port", Value: &value2},
+			event.TestDataSetKeyValue,
+		}
+	)
+
+	testCases := []struct {
+		name       string
+		args       []string
+		wantOutput string
+	}{
+		{
+			name:       "Namespaced keys (plain output)",
+			args:       []string{"list"},
+			wantOutput: "key=value\nservice/bar/version=2.0.0\nservice/foo/port=2.0.0\nservice/foo/version=1.0.0\n",
+		},
+		{
+			name: "Namespaced keys (json output)",
+			args: []string{"list", "--output", "json"},
+			wantOutput: `{
+  "key": "value",
+  "service": {
+    "bar": {
+      "version": "2.0.0"
+    },
+    "foo": {
+      "port": "2.0.0",
+      "version": "1.0.0"
+    }
+  }
+}
+`,
+		},
+		{
+			name:       "Keys inside namespace (plain output)",
+			args:       []string{"list", "--namespace", "service/foo"},
+			wantOutput: "port=2.0.0\nversion=1.0.0\n",
+		},
+		{
+			name:       "Keys inside namespace (json output)",
+			args:       []string{"list", "--namespace", "service", "--output", "json", "--match", "*/version"},
+			wantOutput: "{\n  \"bar\": {\n    \"version\": \"2.0.0\"\n  },\n  \"foo\": {\n    \"version\": \"1.0.0\"\n  }\n}\n",
+		},
+		{
+			name:       "Namespaced keys (toml output)",
+			args:       []string{"list", "--output", "toml", "--prefix", "service/foo"},
+			wantOutput: "\"service/foo/port\" = \"2.0.0\"\n\"service/foo/version\" = \"1.0.0\"\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", namespace)
+
+				args := disableFetch(tc.args)
+				gotOutput, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantOutput, gotOutput)
+			})
+		})
+	}
+}
+
+func TestListNamespaceConflict(t *testing.T) {
+	testCases := []struct {
+		name string
+		keys []string
+	}{
+		{
+			name: "Value set before namespace",
+			keys: []string{"service", "service/foo"},
+		},
+		{
+			name: "Namespace set before value",
+			keys: []string{"service/foo/version", "service/foo"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values := ginokeva.NewValues()
+			for _, k := range tc.keys {
+				values.Add(k, ginokeva.Value(TestDataDummyValue))
+			}
+			values.SetSortOrder(ginokeva.SortByLastModified)
+
+			_, err := convertValuesToOutput(values, "json")
+			if assert.Error(t, err) {
+				assert.IsType(t, &ginokeva.NamespaceConflict{}, err)
+			}
+		})
+	}
+}
+
+func TestListInvalidNamespace(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		args := disableFetch([]string{"list", "--namespace", "service/"})
+		_, err := newTestRepo(t, backend).run(args...)
+
+		if assert.Error(t, err) {
+			assert.IsType(t, &event.InvalidKey{}, err)
+		}
+	})
+}
+
+func TestListTypedValues(t *testing.T) {
+	var (
+		valueTrue    = "true"
+		valueJSON    = `{"replicas":3,"tags":["a"]}`
+		valueList    = `["eu-west","us-east"]`
+		legacyNumber = "42"
+		typed        = []event.Event{
+			event.TestDataSetCounterInt12,
+			{EventType: event.Set, Key: "enabled", Value: &valueTrue, ValueType: event.Bool},
+			{EventType: event.Set, Key: "config", Value: &valueJSON, ValueType: event.JSON},
+			{EventType: event.Set, Key: "regions", Value: &valueList, ValueType: event.List},
+			{EventType: event.Set, Key: "untyped", Value: &legacyNumber},
+		}
+	)
+
+	testCases := []struct {
+		name       string
+		args       []string
+		wantOutput string
+	}{
+		{
+			name: "Typed values (plain output)",
+			args: []string{"list"},
+			wantOutput: "config={\"replicas\":3,\"tags\":[\"a\"]}\ncounter=12\nenabled=true\n" +
+				"regions=[\"eu-west\",\"us-east\"]\nuntyped=42\n",
+		},
+		{
+			name: "Typed values (json output)",
+			args: []string{"list", "--output", "json"},
+			wantOutput: `{
+  "config": {
+    "replicas": 3,
+    "tags": [
+      "a"
+    ]
+  },
+  "counter": 12,
+  "enabled": true,
+  "regions": [
+    "eu-west",
+    "us-east"
+  ],
+  "untyped": "42"
+}
+`,
+		},
+		{
+			name: "Typed values (yaml output)",
+			args: []string{"list", "--output", "yaml"},
+			wantOutput: `config:
+    replicas: 3
+    tags:
+        - a
+counter: 12
+enabled: true
+regions:
+    - eu-west
+    - us-east
+untyped: "42"
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", typed)
+
+				args := disableFetch(tc.args)
+				gotOutput, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantOutput, gotOutput)
+			})
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/spf13/cobra"
+)
+
+func addMigrateCommandTo(root *cobra.Command) {
+	var (
+		dryRun bool
+		push   bool
+	)
+
+	var migrateCommand = &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate notes to the current note format",
+		Long: `Rewrite all notes in the notes reference which use an older note format, including the legacy format
+from before events were introduced, into the current format. Use --dry-run to only list the notes to migrate`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			store := newStore(GetGitWrapperFrom(cmd.Context()))
+
+			if globalFlags.Fetch {
+				err = store.Fetch()
+				if err != nil {
+					return err
+				}
+			}
+
+			migrations, err := store.Migrate(dryRun)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), convertMigrationsToOutput(migrations, dryRun))
+
+			if dryRun || len(migrations) == 0 {
+				return nil
+			}
+
+			err = store.Prune()
+			if err != nil {
+				return err
+			}
+
+			if push {
+				err = store.Push()
+			}
+
+			return err
+		},
+		Args: cobra.NoArgs,
+	}
+
+	migrateCommand.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the notes to migrate, without rewriting them")
+	migrateCommand.Flags().BoolVar(&push, "push", false, "Push notes to upstream")
+	root.AddCommand(migrateCommand)
+}
+
+func convertMigrationsToOutput(migrations []ginokeva.NoteMigration, dryRun bool) (out string) {
+	verb := "Migrated"
+	if dryRun {
+		verb = "Would migrate"
+	}
+
+	for _, m := range migrations {
+		out += fmt.Sprintf("%s note on %s from version %d to %d\n", verb, m.Hash, m.FromVersion, event.CurrentVersion)
+	}
+	out += fmt.Sprintf("%s %d note(s)\n", verb, len(migrations))
+
+	return out
+}
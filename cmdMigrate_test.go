@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateCommand(t *testing.T) {
+	currentNote := eventsJSON(t, []event.Event{event.TestDataSetFooBar})
+	migratedLegacyNote := eventsJSON(t, []event.Event{*event.NewCheckpointEvent([]event.SnapshotEntry{
+		{Key: event.TestDataKey, Value: event.TestDataValue},
+	})})
+	legacyNote := `{"key":"value"}`
+	v1Note := `{"events":[{"type":"set","key":"foo","value":"bar"}]}`
+
+	testCases := []struct {
+		name         string
+		args         []string
+		dryRun       bool
+		wantedOutput string
+	}{
+		{
+			name:         "Migrate all old notes",
+			args:         []string{"migrate"},
+			wantedOutput: "Migrated note on %v from version %v to 2\n",
+		},
+		{
+			name:         "Dry-run",
+			args:         []string{"migrate", "--dry-run"},
+			dryRun:       true,
+			wantedOutput: "Would migrate note on %v from version %v to 2\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				hashes := []string{}
+				for _, note := range []string{legacyNote, v1Note, currentNote} {
+					hashes = append(hashes, repo.commit("Commit"))
+					repo.git("notes", "--ref", repo.notesRef, "add", "-m", note)
+				}
+				legacy, v1, current := hashes[0], hashes[1], hashes[2]
+				versions := map[string]int{legacy: 0, v1: 1}
+
+				args := disableFetch(tc.args)
+				gotOutput, err := repo.run(args...)
+
+				// Notes are migrated in the order they're listed, which is by commit hash
+				migrated := []string{legacy, v1}
+				sort.Strings(migrated)
+				wantedOutput := ""
+				for _, hash := range migrated {
+					wantedOutput += fmt.Sprintf(tc.wantedOutput, hash, versions[hash])
+				}
+				if tc.dryRun {
+					wantedOutput += "Would migrate 2 note(s)\n"
+				} else {
+					wantedOutput += "Migrated 2 note(s)\n"
+				}
+
+				assert.NoError(t, err)
+				assert.Equal(t, wantedOutput, gotOutput)
+				if tc.dryRun {
+					assert.Equal(t, legacyNote, repo.note(legacy))
+					assert.Equal(t, v1Note, repo.note(v1))
+				} else {
+					assert.Equal(t, migratedLegacyNote, repo.note(legacy))
+					assert.Equal(t, currentNote, repo.note(v1))
+				}
+				assert.Equal(t, currentNote, repo.note(current))
+			})
+		})
+	}
+}
+
+func TestMigrateLegacyRemovedKeys(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		repo := newTestRepo(t, backend)
+		for _, note := range []string{`{"A":"1","B":"2"}`, `{"A":"1"}`} {
+			repo.commit("Commit")
+			repo.git("notes", "--ref", repo.notesRef, "add", "-m", note)
+		}
+
+		_, err := repo.run(disableFetch([]string{"migrate"})...)
+		assert.NoError(t, err)
+
+		gotOutput, err := repo.run(disableFetch([]string{"list"})...)
+		assert.NoError(t, err)
+		assert.Equal(t, "A=1\n", gotOutput)
+	})
+}
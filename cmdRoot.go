@@ -6,6 +6,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -21,6 +22,16 @@ in git notes. You can store any sort of data you want against each commit in you
 repository`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
 			initializeConfig(cmd)
+
+			// Other GitWrappers (like test-doubles) don't need a backend to be selected
+			if ctx := cmd.Context(); ctx != nil {
+				if backend, ok := ctx.Value(notesContextKey).(*gitBackend); ok {
+					err = backend.selectBackend(globalFlags.Backend)
+					if err == nil {
+						backend.recordNotesTip(newStore(backend))
+					}
+				}
+			}
 			return err
 		},
 	}
@@ -30,7 +41,18 @@ repository`,
 	addListCommandTo(rootCommand)
 	addGetCommandTo(rootCommand)
 	addSetCommandTo(rootCommand)
+	addCasCommandTo(rootCommand)
 	addUnsetCommandTo(rootCommand)
+	addApplyCommandTo(rootCommand)
+	addImportCommandTo(rootCommand)
+	addIncrCommandTo(rootCommand)
+	addDecrCommandTo(rootCommand)
+	addAppendCommandTo(rootCommand)
+	addRemoveCommandTo(rootCommand)
+	addHistoryCommandTo(rootCommand)
+	addDiffCommandTo(rootCommand)
+	addMigrateCommandTo(rootCommand)
+	addCompactCommandTo(rootCommand)
 	addVersionCommandTo(rootCommand)
 
 	return rootCommand
@@ -73,7 +95,12 @@ func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 
 func addRootFlagsTo(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(&globalFlags.NotesRef, "ref", "gino_keva", "Name of notes reference")
+	cmd.PersistentFlags().StringSliceVar(&globalFlags.Remotes, "remote", []string{ginokeva.DefaultRemote}, "Remote(s) to fetch notes from and push notes to, in order")
+	cmd.PersistentFlags().StringVar(&globalFlags.Rev, "at", "HEAD", "Commit-ish (hash, branch, tag, ...) to operate on")
 	cmd.PersistentFlags().BoolVarP(&globalFlags.VerboseLog, "verbose", "v", false, "Turn on verbose logging")
+	cmd.PersistentFlags().StringVar(&globalFlags.Backend, "backend", backendCLI, "Git implementation to use: cli (git binary) or native (built-in)")
 
 	cmd.PersistentFlags().BoolVar(&globalFlags.Fetch, "fetch", true, "Fetch notes from upstream")
+	cmd.PersistentFlags().IntVar(&globalFlags.CheckpointInterval, "checkpoint-interval", 0, "Write a checkpoint when changing a key if this many notes were written since the last one (0 to disable)")
+	cmd.PersistentFlags().BoolVar(&globalFlags.RecordIdentity, "record-identity", false, "Record the email address of the git user and the hostname on changes, besides the name of the git user")
 }
@@ -1,11 +1,11 @@
 package main
 
 import (
-	"context"
-	"errors"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/philips-software/gino-keva/internal/event"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,124 +44,165 @@ func TestFlagResolution(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			root := NewRootCommand()
-
 			os.Setenv("GINO_KEVA_REF", tc.envVar)
 			defer os.Unsetenv("GINO_KEVA_REF")
 
 			listFlagArgs := []string{"show-flag", "ref"}
 			args := append(listFlagArgs, tc.flagArgs...)
 
-			ctx := ContextWithGitWrapper(context.Background(), &notesStub{})
-			gotOutput, err := executeCommandContext(ctx, root, args...)
+			forEachBackend(t, func(t *testing.T, backend string) {
+				gotOutput, err := newTestRepo(t, backend).run(args...)
 
-			assert.NoError(t, err)
-			assert.Equal(t, tc.wantOutput, gotOutput)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantOutput, gotOutput)
+			})
 		})
 	}
 }
 
 func TestFetchFlag(t *testing.T) {
 	testCases := []struct {
-		name            string
-		args            []string
-		wantFetchCalled bool
+		name       string
+		args       []string
+		wantOutput string
 	}{
 		{
-			name:            "Fetch is called when calling list (default)",
-			args:            []string{"list"},
-			wantFetchCalled: true,
+			name:       "Fetch is called when calling list (default)",
+			args:       []string{"list"},
+			wantOutput: "key=value\n",
 		},
 		{
-			name:            "Fetch is NOT called if set to false",
-			args:            disableFetch([]string{"list"}),
-			wantFetchCalled: false,
+			name:       "Fetch is NOT called if set to false",
+			args:       disableFetch([]string{"list"}),
+			wantOutput: "",
 		}}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			var fetchCalled bool
-			gitWrapper := &notesStub{
-				fetchNotesImplementation: spyArgsString(&fetchCalled, nil),
-				logCommitsImplementation: dummyStubArgsNone,
-				notesListImplementation:  dummyStubArgsString,
-				notesShowImplementation:  dummyStubArgsStringString,
-			}
-			ctx := ContextWithGitWrapper(context.Background(), gitWrapper)
-
-			root := NewRootCommand()
-			_, err := executeCommandContext(ctx, root, tc.args...)
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.addRemote("origin")
+				repo.setRemoteNote("origin", "HEAD", []event.Event{event.TestDataSetKeyValue})
 
-			assert.NoError(t, err)
-			assert.Equal(t, tc.wantFetchCalled, fetchCalled)
+				gotOutput, err := repo.run(tc.args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantOutput, gotOutput)
+			})
 		})
 	}
 }
 
 func TestPushFlag(t *testing.T) {
 	testCases := []struct {
-		name           string
-		args           []string
-		wantPushCalled bool
+		name       string
+		args       []string
+		wantPushed bool
 	}{
 		{
-			name:           "Push is NOT called when flag is unset (default)",
-			args:           []string{"set", "foo", "bar"},
-			wantPushCalled: false,
+			name:       "Push is NOT called when flag is unset (default)",
+			args:       []string{"set", "foo", "bar"},
+			wantPushed: false,
 		},
 		{
-			name:           "Push is called when flag is set",
-			args:           enablePush([]string{"set", "foo", "bar"}),
-			wantPushCalled: true,
+			name:       "Push is called when flag is set",
+			args:       enablePush([]string{"set", "foo", "bar"}),
+			wantPushed: true,
 		}}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			var pushCalled bool
-			gitWrapper := &notesStub{
-				pushNotesImplementation:    spyArgsString(&pushCalled, nil),
-				revParseHeadImplementation: responseStubArgsNone(TestDataDummyHash),
-				logCommitsImplementation:   dummyStubArgsNone,
-				notesAddImplementation:     dummyStubArgsStringString,
-				notesListImplementation:    dummyStubArgsString,
-				notesShowImplementation:    dummyStubArgsStringString,
-			}
-			ctx := ContextWithGitWrapper(context.Background(), gitWrapper)
-
-			root := NewRootCommand()
-
-			args := disableFetch(tc.args)
-			_, err := executeCommandContext(ctx, root, args...)
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				remoteDir := repo.addRemote("origin")
 
-			assert.NoError(t, err)
-			assert.Equal(t, tc.wantPushCalled, pushCalled)
+				args := disableFetch(tc.args)
+				_, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantPushed, repo.remoteNote(remoteDir, "HEAD") != "")
+			})
 		})
 	}
 }
 
 func TestFetchNoUpstreamRef(t *testing.T) {
-	var fetchStubNoUpstreamRef = func(string) (response string, err error) {
-		// Mimic error when there's no upstream ref present yet
-		err = errors.New("exit status 128")
-		response = "fatal: couldn't find remote ref refs/notes/foo"
+	t.Run("Fetch without upstream notesref doesn't result in error", func(t *testing.T) {
+		forEachBackend(t, func(t *testing.T, backend string) {
+			repo := newTestRepo(t, backend)
+			repo.addRemote("origin")
 
-		return response, err
-	}
+			gotOutput, err := repo.run("list")
 
-	t.Run("Fetch without upstream notesref doesn't result in error", func(t *testing.T) {
-		root := NewRootCommand()
-		gitWrapper := &notesStub{
-			fetchNotesImplementation: fetchStubNoUpstreamRef,
-			logCommitsImplementation: dummyStubArgsNone,
-			notesListImplementation:  dummyStubArgsString,
-			notesShowImplementation:  dummyStubArgsStringString,
-		}
-		ctx := ContextWithGitWrapper(context.Background(), gitWrapper)
-
-		args := []string{"list"}
-		gotOutput, err := executeCommandContext(ctx, root, args...)
-
-		assert.NoError(t, err)
-		assert.Equal(t, TestDataEmptyString, gotOutput)
+			assert.NoError(t, err)
+			assert.Equal(t, TestDataEmptyString, gotOutput)
+		})
 	})
 }
+
+func TestRemoteFlag(t *testing.T) {
+	testCases := []struct {
+		name        string
+		envVar      string
+		args        []string
+		wantRemotes []string
+	}{
+		{
+			name:        "Fetch from and push to origin by default",
+			args:        []string{"set", "foo", "bar"},
+			wantRemotes: []string{"origin"},
+		},
+		{
+			name:        "Fetch from and push to the remote provided via command line",
+			args:        []string{"set", "foo", "bar", "--remote", "upstream"},
+			wantRemotes: []string{"upstream"},
+		},
+		{
+			name:        "Fetch from and push to each of multiple remotes",
+			args:        []string{"set", "foo", "bar", "--remote", "upstream,mirror"},
+			wantRemotes: []string{"upstream", "mirror"},
+		},
+		{
+			name:        "Set remotes with an environment variable",
+			envVar:      "upstream,mirror",
+			args:        []string{"set", "foo", "bar"},
+			wantRemotes: []string{"upstream", "mirror"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Setenv("GINO_KEVA_REMOTE", tc.envVar)
+			defer os.Unsetenv("GINO_KEVA_REMOTE")
+
+			forEachBackend(t, func(t *testing.T, backend string) {
+				// Each remote holds a key named after it, which is only present locally once fetched
+				repo := newTestRepo(t, backend)
+				remoteDirs := map[string]string{}
+				for _, remote := range []string{"origin", "upstream", "mirror"} {
+					remoteDirs[remote] = repo.addRemote(remote)
+					value := "1"
+					repo.setRemoteNote(remote, "HEAD", []event.Event{{EventType: event.Set, Key: remote, Value: &value}})
+				}
+
+				args := enablePush(tc.args)
+				_, err := repo.run(args...)
+				assert.NoError(t, err)
+
+				wantOutput := "foo=bar\n"
+				for _, remote := range tc.wantRemotes {
+					wantOutput += remote + "=1\n"
+				}
+				gotOutput, err := repo.run("list", "--fetch=false", "--sort", "modified")
+				assert.NoError(t, err)
+				assert.Equal(t, wantOutput, gotOutput, "Keys of the fetched remotes")
+
+				for remote, dir := range remoteDirs {
+					wantPushed := strings.Contains(strings.Join(tc.wantRemotes, ","), remote)
+					gotPushed := strings.Contains(repo.remoteNote(dir, "HEAD"), `"foo"`)
+					assert.Equal(t, wantPushed, gotPushed, "Pushed to %v", remote)
+				}
+			})
+		})
+	}
+}
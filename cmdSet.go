@@ -1,77 +1,150 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/philips-software/gino-keva/internal/event"
-	log "github.com/sirupsen/logrus"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
 	"github.com/spf13/cobra"
 )
 
 func addSetCommandTo(root *cobra.Command) {
 	var (
-		push bool
+		push      bool
+		valueType string
+		ifAbsent  bool
+		ifEquals  string
+		ttl       time.Duration
+		expiresAt string
+		message   string
 	)
 
 	var setCommand = &cobra.Command{
-		Use:   "set [key] [value]",
-		Short: "Set the value of a key",
-		Long:  `Set the value of a key`,
+		Use:   "set [key] [value] | set [key=value]...",
+		Short: "Set the value of one or more keys",
+		Long: `Set the value of a key, or set multiple keys at once by providing key=value pairs.
+All keys are written in a single note update.
+Use --if-absent or --if-equals to only set the value(s) if the current value of every key matches.
+The condition is checked again when the command is retried because upstream changed.
+Use --ttl or --expires-at to have the value(s) expire, after which they are treated as unset`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			key := args[0]
-			value := args[1]
+			keyValues, err := parseSetArgs(args)
+			if err != nil {
+				return err
+			}
+
+			t, err := event.ParseValueType(valueType)
+			if err != nil {
+				return err
+			}
+			expiry, err := parseExpiry(ttl, expiresAt)
+			if err != nil {
+				return err
+			}
+
+			for i := range keyValues {
+				keyValues[i].ValueType = t
+				keyValues[i].ExpiresAt = expiry
+			}
+
+			precondition := ginokeva.Precondition{Absent: ifAbsent}
+			if cmd.Flags().Changed("if-equals") {
+				if ifAbsent {
+					return fmt.Errorf("--if-absent and --if-equals cannot be combined")
+				}
+				precondition.Expected = &ifEquals
+			}
+
 			gitWrapper := GetGitWrapperFrom(cmd.Context())
+			store := newStore(gitWrapper)
 
 			if globalFlags.Fetch {
-				err = fetchNotes(gitWrapper)
+				err = store.Fetch()
 				if err != nil {
 					return err
 				}
 			}
 
-			err = set(gitWrapper, globalFlags.NotesRef, key, value)
+			metadata := collectMetadata(gitWrapper, message)
+			err = store.SetIf(keyValues, precondition, metadata)
 			if err != nil {
 				return err
 			}
 
-			err = pruneNotes(gitWrapper, globalFlags.NotesRef)
+			err = store.Prune()
 			if err != nil {
 				return err
 			}
 
 			if push {
-				err = pushNotes(gitWrapper, globalFlags.NotesRef)
+				err = store.Push()
 			}
 
 			return err
 		},
-		Args: cobra.ExactArgs(2),
+		Args: cobra.MinimumNArgs(1),
 	}
 
 	setCommand.Flags().BoolVar(&push, "push", false, "Push notes to upstream")
+	setCommand.Flags().StringVar(&valueType, "type", "string", "Set value type (string/int/bool/json/list)")
+	setCommand.Flags().BoolVar(&ifAbsent, "if-absent", false, "Only set if the key is not set yet")
+	setCommand.Flags().StringVar(&ifEquals, "if-equals", "", "Only set if the current value equals the provided value")
+	setCommand.Flags().StringVarP(&message, "message", "m", "", "Record a message explaining the change")
+	setCommand.Flags().DurationVar(&ttl, "ttl", 0, "Expire the value after this duration (e.g. 30m, 2h)")
+	setCommand.Flags().StringVar(&expiresAt, "expires-at", "", "Expire the value at this moment (RFC3339, e.g. 2022-07-01T12:00:00Z)")
 	root.AddCommand(setCommand)
 }
 
-func set(gitWrapper GitWrapper, notesRef string, key string, value string) error {
-	setEvent, err := event.NewSetEvent(key, value)
-	if err != nil {
-		return err
-	}
+// parseExpiry returns the moment of expiry based on either the time-to-live or the absolute moment of expiry
+func parseExpiry(ttl time.Duration, expiresAt string) (*time.Time, error) {
+	switch {
+	case ttl != 0 && expiresAt != "":
+		return nil, fmt.Errorf("--ttl and --expires-at cannot be combined")
+
+	case ttl < 0:
+		return nil, fmt.Errorf("invalid ttl: %v", ttl)
+
+	case ttl > 0:
+		expiry := now().Add(ttl).UTC().Truncate(time.Second)
+		return &expiry, nil
 
-	events, err := getEvents(gitWrapper, notesRef)
-	if err != nil {
-		return err
+	case expiresAt != "":
+		expiry, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry: %v", expiresAt)
+		}
+		expiry = expiry.UTC()
+		return &expiry, nil
+
+	default:
+		return nil, nil
 	}
+}
 
-	*events = event.AddNewEvent(events, setEvent)
+// parseSetArgs supports both the "key value" and the "key=value..." notation
+func parseSetArgs(args []string) (keyValues []ginokeva.KeyValue, err error) {
+	if len(args) == 2 && !strings.Contains(args[0], "=") {
+		return []ginokeva.KeyValue{{Key: args[0], Value: args[1]}}, nil
+	}
 
-	err = persistEvents(gitWrapper, notesRef, events)
-	if err != nil {
-		return err
+	for _, arg := range args {
+		kv, err := parseKeyValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		keyValues = append(keyValues, *kv)
 	}
 
-	log.WithFields(log.Fields{
-		"key":   key,
-		"value": value,
-	}).Debug("Set event added successfully")
+	return keyValues, nil
+}
+
+func parseKeyValue(s string) (*ginokeva.KeyValue, error) {
+	fields := strings.SplitN(s, "=", 2)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected key=value, got: %v", s)
+	}
 
-	return nil
+	return &ginokeva.KeyValue{Key: fields[0], Value: fields[1]}, nil
 }
@@ -1,11 +1,11 @@
 package main
 
 import (
-	"context"
-	"errors"
 	"testing"
+	"time"
 
 	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,6 +13,7 @@ func TestSetCommand(t *testing.T) {
 	testCases := []struct {
 		name         string
 		args         []string
+		notesRef     string
 		startEvents  []event.Event
 		wantedEvents []event.Event
 	}{
@@ -26,61 +27,180 @@ func TestSetCommand(t *testing.T) {
 			name:         "Start key=value, set foo=bar (non-default ref)",
 			startEvents:  []event.Event{event.TestDataSetKeyValue},
 			args:         []string{"set", "foo", "bar", "--ref", "non_default"},
+			notesRef:     "non_default",
 			wantedEvents: []event.Event{event.TestDataSetFooBar, event.TestDataSetKeyValue},
 		},
+		{
+			name:         "Start empty, set key=value and foo=bar at once",
+			startEvents:  []event.Event{},
+			args:         []string{"set", "key=value", "foo=bar"},
+			wantedEvents: []event.Event{event.TestDataSetFooBar, event.TestDataSetKeyValue},
+		},
+		{
+			name:         "Start empty, set counter=12 (int)",
+			startEvents:  []event.Event{},
+			args:         []string{"set", "counter", "12", "--type", "int"},
+			wantedEvents: []event.Event{event.TestDataSetCounterInt12},
+		},
+		{
+			name:         "Start empty, set counter=12 (int) normalized",
+			startEvents:  []event.Event{},
+			args:         []string{"set", "counter=+12", "--type", "int"},
+			wantedEvents: []event.Event{event.TestDataSetCounterInt12},
+		},
+		{
+			name:         "Start empty, set single key=value pair",
+			startEvents:  []event.Event{},
+			args:         []string{"set", "key=value"},
+			wantedEvents: []event.Event{event.TestDataSetKeyValue},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			starteventsJSON, _ := event.Marshal(&tc.startEvents)
-			wantedeventsJSON, _ := event.Marshal(&tc.wantedEvents)
-
-			root := NewRootCommand()
-			var notesAddArgMsg string
-			gitWrapper := &notesStub{
-				notesAddImplementation:     spyArgsStringString(nil, nil, &notesAddArgMsg),
-				notesShowImplementation:    responseStubArgsStringString(starteventsJSON),
-				revParseHeadImplementation: responseStubArgsNone(TestDataDummyHash),
-			}
-			ctx := ContextWithGitWrapper(context.Background(), gitWrapper)
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				if tc.notesRef != "" {
+					repo.notesRef = tc.notesRef
+				}
+				repo.setNote("HEAD", tc.startEvents)
 
-			args := disableFetch(tc.args)
-			_, err := executeCommandContext(ctx, root, args...)
+				args := disableFetch(tc.args)
+				_, err := repo.run(args...)
 
-			assert.NoError(t, err)
-			assert.Equal(t, wantedeventsJSON, notesAddArgMsg)
+				assert.NoError(t, err)
+				assert.Equal(t, eventsJSON(t, tc.wantedEvents), repo.note("HEAD"))
+			})
 		})
 	}
 }
 
-func TestSetInvalidKey(t *testing.T) {
-	gitWrapper := &notesStub{}
+func TestSetAtRevision(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend string) {
+		repo := newTestRepo(t, backend)
+		repo.git("tag", "v1.4.0")
+		repo.commit("Next")
 
-	t.Run("Key cannot be empty", func(t *testing.T) {
-		err := set(gitWrapper, TestDataDummyRef, TestDataEmptyString, TestDataDummyValue)
-		if assert.Error(t, err) {
-			assert.IsType(t, &event.InvalidKey{}, err)
-		}
+		args := disableFetch(atRev([]string{"set", "foo", "bar"}, "v1.4.0"))
+		_, err := repo.run(args...)
+
+		assert.NoError(t, err)
+		assert.Equal(t, eventsJSON(t, []event.Event{event.TestDataSetFooBar}), repo.note("v1.4.0"), "Set event is added to the note of the commit the revision resolves to")
+		assert.Equal(t, "", repo.note("HEAD"))
 	})
 }
 
-func TestSetWithoutHeadEvents(t *testing.T) {
-	var showStubNoNote = func(string, string) (response string, err error) {
-		// Mimic error when ther's no note available
-		err = errors.New("exit status 128")
-		response = "error: no note found for object DUMMY_HASH.\n"
+func TestParseSetArgs(t *testing.T) {
+	testCases := []struct {
+		name    string
+		args    []string
+		wanted  []ginokeva.KeyValue
+		wantErr bool
+	}{
+		{
+			name:   "Key and value",
+			args:   []string{"key", "value"},
+			wanted: []ginokeva.KeyValue{{Key: "key", Value: "value"}},
+		},
+		{
+			name:   "Key/value pairs",
+			args:   []string{"key=value", "foo=bar=baz", "empty="},
+			wanted: []ginokeva.KeyValue{{Key: "key", Value: "value"}, {Key: "foo", Value: "bar=baz"}, {Key: "empty", Value: ""}},
+		},
+		{
+			name:    "Missing value",
+			args:    []string{"key"},
+			wantErr: true,
+		},
+		{
+			name:    "Missing value in one of the pairs",
+			args:    []string{"key=value", "foo", "bar=baz"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseSetArgs(tc.args)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wanted, got)
+			}
+		})
+	}
+}
+
+func TestSetInvalidTypedValue(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		wantedError error
+	}{
+		{
+			name:        "Value doesn't match type",
+			args:        []string{"set", "counter", "twelve", "--type", "int"},
+			wantedError: &event.InvalidValue{},
+		},
+		{
+			name:        "Unknown type",
+			args:        []string{"set", "counter", "12", "--type", "float"},
+			wantedError: &event.UnknownValueType{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+
+				args := disableFetch(tc.args)
+				_, err := repo.run(args...)
 
-		return response, err
+				if assert.Error(t, err) {
+					assert.IsType(t, tc.wantedError, err)
+				}
+				assert.Equal(t, "", repo.note("HEAD"), "No note should be written")
+			})
+		})
 	}
+}
 
-	gitWrapper := &notesStub{
-		notesShowImplementation:    showStubNoNote,
-		revParseHeadImplementation: responseStubArgsNone(TestDataDummyHash),
-		notesAddImplementation:     dummyStubArgsStringString,
+func TestSetWithExpiry(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC) }
+
+	expiry := time.Date(2022, 7, 1, 14, 0, 0, 0, time.UTC)
+	wantedEvent := event.TestDataSetKeyValue
+	wantedEvent.ExpiresAt = &expiry
+
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "Set with ttl",
+			args: []string{"set", "key", "value", "--ttl", "2h"},
+		},
+		{
+			name: "Set with expires-at",
+			args: []string{"set", "key", "value", "--expires-at", "2022-07-01T16:00:00+02:00"},
+		},
 	}
 
-	t.Run("Set without prior events on HEAD commit doesn't fail", func(t *testing.T) {
-		err := set(gitWrapper, TestDataDummyRef, event.TestDataFoo, event.TestDataBar)
-		assert.NoError(t, err)
-	})
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+
+				args := disableFetch(tc.args)
+				_, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, eventsJSON(t, []event.Event{wantedEvent}), repo.note("HEAD"))
+			})
+		})
+	}
 }
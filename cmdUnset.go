@@ -1,70 +1,48 @@
 package main
 
 import (
-	"github.com/philips-software/gino-keva/internal/event"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 func addUnsetCommandTo(root *cobra.Command) {
 	var (
-		push bool
+		push    bool
+		message string
 	)
 
 	var unsetCommand = &cobra.Command{
-		Use:   "unset [key]",
-		Short: "Unset a key",
-		Long:  `Unset a key`,
+		Use:   "unset [key]...",
+		Short: "Unset one or more keys",
+		Long: `Unset one or more keys.
+All keys are written in a single note update`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			key := args[0]
+			keys := args
 			gitWrapper := GetGitWrapperFrom(cmd.Context())
+			store := newStore(gitWrapper)
 
 			if globalFlags.Fetch {
-				err = fetchNotes(gitWrapper)
+				err = store.Fetch()
 				if err != nil {
 					return err
 				}
 			}
 
-			err = unset(gitWrapper, globalFlags.NotesRef, key)
+			metadata := collectMetadata(gitWrapper, message)
+			err = store.UnsetWithMetadata(keys, metadata)
 			if err != nil {
 				return err
 			}
 
 			if push {
-				err = pushNotes(gitWrapper, globalFlags.NotesRef)
+				err = store.Push()
 			}
 
 			return err
 		},
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MinimumNArgs(1),
 	}
 
 	unsetCommand.Flags().BoolVar(&push, "push", false, "Push notes to upstream")
+	unsetCommand.Flags().StringVarP(&message, "message", "m", "", "Record a message explaining the change")
 	root.AddCommand(unsetCommand)
 }
-
-func unset(gitWrapper GitWrapper, notesRef string, key string) error {
-	unsetEvent, err := event.NewUnsetEvent(key)
-	if err != nil {
-		return err
-	}
-
-	events, err := getEvents(gitWrapper, notesRef)
-	if err != nil {
-		return err
-	}
-
-	*events = event.AddNewEvent(events, unsetEvent)
-
-	err = persistEvents(gitWrapper, notesRef, events)
-	if err != nil {
-		return err
-	}
-
-	log.WithFields(log.Fields{
-		"key": key,
-	}).Debug("Unset event added successfully")
-
-	return nil
-}
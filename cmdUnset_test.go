@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"testing"
 
 	"github.com/philips-software/gino-keva/internal/event"
@@ -21,47 +20,29 @@ func TestUnsetCommand(t *testing.T) {
 			args:   []string{"unset", "key"},
 			wanted: []event.Event{event.TestDataUnsetKey},
 		},
+		{
+			name:  "Unset multiple keys at once",
+			start: []event.Event{},
+			args:  []string{"unset", "foo", "key"},
+			wanted: []event.Event{
+				event.TestDataUnsetKey,
+				{EventType: event.Unset, Key: event.TestDataFoo},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			starteventsJSON, _ := event.Marshal(&tc.start)
-			wantedeventsJSON, _ := event.Marshal(&tc.wanted)
-			root := NewRootCommand()
-
-			var notesAddArgMsg string
-			gitWrapper := &notesStub{
-				logCommitsImplementation:   responseStubArgsNone(simpleLogCommitsResponse),
-				notesListImplementation:    responseStubArgsString(simpleNotesListResponse),
-				notesAddImplementation:     spyArgsStringString(nil, nil, &notesAddArgMsg),
-				notesShowImplementation:    responseStubArgsStringString(starteventsJSON),
-				revParseHeadImplementation: responseStubArgsNone(TestDataDummyHash),
-			}
-
-			ctx := ContextWithGitWrapper(context.Background(), gitWrapper)
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.setNote("HEAD", tc.start)
 
-			args := disableFetch(tc.args)
-			_, err := executeCommandContext(ctx, root, args...)
+				args := disableFetch(tc.args)
+				_, err := repo.run(args...)
 
-			assert.NoError(t, err)
-			assert.Equal(t, wantedeventsJSON, notesAddArgMsg)
+				assert.NoError(t, err)
+				assert.Equal(t, eventsJSON(t, tc.wanted), repo.note("HEAD"))
+			})
 		})
 	}
 }
-
-func TestUnsetInvalidKey(t *testing.T) {
-	gitWrapper := &notesStub{
-		notesAddImplementation:     dummyStubArgsStringString,
-		revParseHeadImplementation: responseStubArgsNone(TestDataDummyHash),
-		logCommitsImplementation:   dummyStubArgsNone,
-		notesListImplementation:    dummyStubArgsString,
-		notesShowImplementation:    dummyStubArgsStringString,
-	}
-
-	t.Run("Key cannot be empty", func(t *testing.T) {
-		err := unset(gitWrapper, TestDataDummyRef, TestDataEmptyString)
-		if assert.Error(t, err) {
-			assert.IsType(t, &event.InvalidKey{}, err)
-		}
-	})
-}
@@ -15,6 +15,16 @@ const (
 	Set
 	// Unset represents a key to be unset if present
 	Unset
+	// Incr represents the integer value of a key to be incremented by a delta
+	Incr
+	// Decr represents the integer value of a key to be decremented by a delta
+	Decr
+	// Append represents an item to be added to the list value of a key
+	Append
+	// Remove represents an item to be removed from the list value of a key
+	Remove
+	// Checkpoint represents a snapshot of all key/values, which makes older events obsolete
+	Checkpoint
 )
 
 func (t Type) String() string {
@@ -22,13 +32,23 @@ func (t Type) String() string {
 }
 
 var toString = map[Type]string{
-	Set:   "set",
-	Unset: "unset",
+	Set:        "set",
+	Unset:      "unset",
+	Incr:       "incr",
+	Decr:       "decr",
+	Append:     "append",
+	Remove:     "remove",
+	Checkpoint: "checkpoint",
 }
 
 var toID = map[string]Type{
-	"set":   Set,
-	"unset": Unset,
+	"set":        Set,
+	"unset":      Unset,
+	"incr":       Incr,
+	"decr":       Decr,
+	"append":     Append,
+	"remove":     Remove,
+	"checkpoint": Checkpoint,
 }
 
 // MarshalJSON marshals the enum as a quoted json string
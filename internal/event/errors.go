@@ -7,7 +7,16 @@ type NoEventsInNote struct {
 }
 
 func (NoEventsInNote) Error() string {
-	return "Cannot find events key in JSON. Old syntax?"
+	return "Cannot find events key in JSON. Legacy note format? Run 'gino-keva migrate' to convert it"
+}
+
+// UnsupportedVersion error indicates Gino keva ran into a note written in a newer version of the note schema
+type UnsupportedVersion struct {
+	Version int
+}
+
+func (u UnsupportedVersion) Error() string {
+	return fmt.Sprintf("Unsupported note version: %d. Please upgrade gino-keva", u.Version)
 }
 
 // UnknownType error indicates Gino keva ran into an unknown event type stored in Git Notes
@@ -19,6 +28,25 @@ func (u UnknownType) Error() string {
 	return fmt.Sprintf("Unknown event type: %s", u.EventType)
 }
 
+// UnknownValueType error indicates Gino keva ran into an unknown value type
+type UnknownValueType struct {
+	ValueType string
+}
+
+func (u UnknownValueType) Error() string {
+	return fmt.Sprintf("Unknown value type: %s", u.ValueType)
+}
+
+// InvalidValue error indicates the value doesn't match its value type
+type InvalidValue struct {
+	value     string
+	valueType ValueType
+}
+
+func (i InvalidValue) Error() string {
+	return fmt.Sprintf("Invalid value for type %v: %v", i.valueType, i.value)
+}
+
 // KeyMissing error indicates Gino keva ran into an event with a missing or empty key
 type KeyMissing struct {
 	event Event
@@ -37,6 +65,24 @@ func (v ValueMissing) Error() string {
 	return fmt.Sprintf("Value missing from event: %v", v.event)
 }
 
+// DeltaMissing error indicates Gino keva ran into an incr/decr event with a missing delta
+type DeltaMissing struct {
+	event Event
+}
+
+func (d DeltaMissing) Error() string {
+	return fmt.Sprintf("Delta missing from event: %v", d.event)
+}
+
+// SnapshotMissing error indicates Gino keva ran into a checkpoint event with a missing snapshot
+type SnapshotMissing struct {
+	event Event
+}
+
+func (s SnapshotMissing) Error() string {
+	return fmt.Sprintf("Snapshot missing from event: %v", s.event)
+}
+
 // InvalidKey error indicates the key is not valid
 type InvalidKey struct {
 	msg string
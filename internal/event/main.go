@@ -1,7 +1,9 @@
 package event
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 )
 
 // AddNewEvent to start of list
@@ -23,6 +25,88 @@ func NewSetEvent(key string, value string) (*Event, error) {
 	}, nil
 }
 
+// NewTypedSetEvent will create a new event of type Set, with a value of the provided value type
+func NewTypedSetEvent(key string, value string, valueType ValueType) (*Event, error) {
+	e, err := NewSetEvent(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, err := NormalizeValue(value, valueType)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Value = &normalized
+	e.ValueType = valueType
+	return e, nil
+}
+
+// NewIncrEvent will create a new event of type Incr
+func NewIncrEvent(key string, delta int64) (*Event, error) {
+	err := validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		EventType: Incr,
+		Key:       key,
+		Delta:     &delta,
+	}, nil
+}
+
+// NewDecrEvent will create a new event of type Decr
+func NewDecrEvent(key string, delta int64) (*Event, error) {
+	err := validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		EventType: Decr,
+		Key:       key,
+		Delta:     &delta,
+	}, nil
+}
+
+// NewAppendEvent will create a new event of type Append
+func NewAppendEvent(key string, item string) (*Event, error) {
+	err := validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		EventType: Append,
+		Key:       key,
+		Value:     &item,
+	}, nil
+}
+
+// NewRemoveEvent will create a new event of type Remove
+func NewRemoveEvent(key string, item string) (*Event, error) {
+	err := validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		EventType: Remove,
+		Key:       key,
+		Value:     &item,
+	}, nil
+}
+
+// NewCheckpointEvent will create a new event of type Checkpoint, holding the provided snapshot. The entries are
+// expected in order of last modification, most recent first
+func NewCheckpointEvent(snapshot []SnapshotEntry) *Event {
+	return &Event{
+		EventType: Checkpoint,
+		Snapshot:  &snapshot,
+	}
+}
+
 // NewUnsetEvent will create a new event of type Unset
 func NewUnsetEvent(key string) (*Event, error) {
 	err := validateKey(key)
@@ -36,11 +120,41 @@ func NewUnsetEvent(key string) (*Event, error) {
 	}, nil
 }
 
+// NamespaceSeparator separates the namespace segments of a key, e.g. service/foo/version
+const NamespaceSeparator = "/"
+
+// ValidateNamespace validates a namespace, which follows the same rules as a key
+func ValidateNamespace(namespace string) error {
+	return validateKey(namespace)
+}
+
 func validateKey(key string) error {
 	if key == "" {
 		return &InvalidKey{msg: "key cannot be empty"}
 	}
 
+	segments := strings.Split(key, NamespaceSeparator)
+	if len(segments) == 1 {
+		return validateKeySegment(key)
+	}
+
+	for _, segment := range segments {
+		if segment == "" {
+			return &InvalidKey{msg: "namespace segment cannot be empty"}
+		}
+
+		err := validateKeySegment(segment)
+		if invalidKey, ok := err.(*InvalidKey); ok {
+			return &InvalidKey{msg: fmt.Sprintf("segment %q: %v", segment, invalidKey.msg)}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateKeySegment(key string) error {
 	{
 		pattern := `[^A-Za-z0-9_-]`
 		matched, err := regexp.Match(pattern, []byte(key))
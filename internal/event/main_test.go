@@ -42,6 +42,41 @@ func TestValidateKey(t *testing.T) {
 			key:   "invalid-",
 			valid: false,
 		},
+		{
+			name:  "Key can be namespaced",
+			key:   "service/foo/version",
+			valid: true,
+		},
+		{
+			name:  "Namespace segment cannot be empty",
+			key:   "service//version",
+			valid: false,
+		},
+		{
+			name:  "Key cannot start with namespace separator",
+			key:   "/service/version",
+			valid: false,
+		},
+		{
+			name:  "Key cannot end with namespace separator",
+			key:   "service/",
+			valid: false,
+		},
+		{
+			name:  "First character of namespace segment is not a letter",
+			key:   "service/2foo/version",
+			valid: false,
+		},
+		{
+			name:  "Last character of namespace segment is not a letter or number",
+			key:   "service/foo-/version",
+			valid: false,
+		},
+		{
+			name:  "Key cannot contain dots",
+			key:   "service.foo",
+			valid: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -3,18 +3,39 @@ package event
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+)
 
-	log "github.com/sirupsen/logrus"
+const (
+	// LegacyVersion is the version of notes written before events were introduced. Such a note holds a plain
+	// JSON object with all key/values at that commit, instead of events
+	LegacyVersion = 0
+	// CurrentVersion is the version of the note schema written by Marshal
+	CurrentVersion = 2
 )
 
+// decoder decodes the events from the top-level JSON object of a note
+type decoder func(note map[string]json.RawMessage) ([]Event, error)
+
+// decoders holds the decoder for each version of the note schema
+var decoders = map[int]decoder{
+	LegacyVersion: decodeLegacy,
+	1:             decodeEvents, // Events, without explicit version
+	2:             decodeEvents, // Events, with explicit version
+}
+
+type envelope struct {
+	Version int      `json:"version"`
+	Events  *[]Event `json:"events"`
+}
+
 // Marshal a list of Event objects into a string
 func Marshal(events *[]Event) (string, error) {
 	if events == nil {
 		events = &[]Event{}
 	}
 
-	wrappedEvents := map[string]*[]Event{"events": events}
-	result, err := json.Marshal(wrappedEvents)
+	result, err := json.Marshal(envelope{Version: CurrentVersion, Events: events})
 	if err != nil {
 		return "", err
 	}
@@ -22,24 +43,98 @@ func Marshal(events *[]Event) (string, error) {
 	return fmt.Sprintf("%s\n", result), nil
 }
 
-// Unmarshal a string into a list of Event objects
+// Unmarshal a string into a list of Event objects. Legacy notes are not decoded, but result in NoEventsInNote
 func Unmarshal(s string, events *[]Event) error {
-	r := make(map[string]json.RawMessage)
-	if err := json.Unmarshal([]byte(s), &r); err != nil {
+	decoded, version, err := Decode(s)
+	if err != nil {
 		return err
 	}
 
-	if eventsJSON, ok := r["events"]; ok {
-		if err := json.Unmarshal(eventsJSON, &events); err != nil {
-			return err
-		}
-	} else {
+	if version == LegacyVersion {
 		return &NoEventsInNote{}
 	}
 
-	for _, e := range *events {
+	*events = decoded
+	return nil
+}
+
+// Decode a string in any known version of the note schema into a list of Event objects. The version of the
+// schema is returned as well
+func Decode(s string) (events []Event, version int, err error) {
+	note := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(s), &note); err != nil {
+		return nil, 0, err
+	}
+
+	version, err = getVersion(note)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	decode, ok := decoders[version]
+	if !ok {
+		return nil, version, &UnsupportedVersion{Version: version}
+	}
+
+	events, err = decode(note)
+	if err != nil {
+		return nil, version, err
+	}
+
+	return events, version, validateEvents(events)
+}
+
+func getVersion(note map[string]json.RawMessage) (version int, err error) {
+	if versionJSON, ok := note["version"]; ok {
+		err = json.Unmarshal(versionJSON, &version)
+		return version, err
+	}
+
+	if _, ok := note["events"]; ok {
+		return 1, nil
+	}
+
+	return LegacyVersion, nil
+}
+
+func decodeEvents(note map[string]json.RawMessage) ([]Event, error) {
+	events := []Event{}
+	if err := json.Unmarshal(note["events"], &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// decodeLegacy converts a legacy note into a checkpoint event holding its key/values, ordered by key. A legacy note
+// holds all key/values at its commit, so keys of older notes which it doesn't hold were removed in the meanwhile
+func decodeLegacy(note map[string]json.RawMessage) ([]Event, error) {
+	keys := []string{}
+	for k := range note {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	snapshot := []SnapshotEntry{}
+	for _, k := range keys {
+		if err := validateKey(k); err != nil {
+			return nil, err
+		}
+
+		var value string
+		if err := json.Unmarshal(note[k], &value); err != nil {
+			value = string(note[k]) // Not a string, so keep the JSON text as value
+		}
+		snapshot = append(snapshot, SnapshotEntry{Key: k, Value: value})
+	}
+
+	return []Event{*NewCheckpointEvent(snapshot)}, nil
+}
+
+func validateEvents(events []Event) error {
+	for _, e := range events {
 		switch e.EventType {
-		case Set:
+		case Set, Append, Remove:
 			if e.Value == nil {
 				return &ValueMissing{e}
 			}
@@ -48,8 +143,24 @@ func Unmarshal(s string, events *[]Event) error {
 			if e.Key == "" {
 				return &KeyMissing{e}
 			}
+		case Incr, Decr:
+			if e.Delta == nil {
+				return &DeltaMissing{e}
+			}
+			if e.Key == "" {
+				return &KeyMissing{e}
+			}
+		case Checkpoint:
+			if e.Snapshot == nil {
+				return &SnapshotMissing{e}
+			}
+			for _, entry := range *e.Snapshot {
+				if entry.Key == "" {
+					return &KeyMissing{e}
+				}
+			}
 		default:
-			log.Fatal("Fatal: Unknown event type encountered")
+			return &UnknownType{EventType: e.EventType.String()}
 		}
 	}
 	return nil
@@ -13,16 +13,29 @@ var (
 	rawEventSetFooBar   = "{\"type\":\"set\",\"key\":\"foo\",\"value\":\"bar\"}"
 	rawEventSetKeyValue = "{\"type\":\"set\",\"key\":\"key\",\"value\":\"value\"}"
 	rawEventUnsetKey    = "{\"type\":\"unset\",\"key\":\"key\"}"
+	rawEventSetCounter  = "{\"type\":\"set\",\"key\":\"counter\",\"value\":\"12\",\"valueType\":\"int\"}"
+	rawEventIncrCounter = "{\"type\":\"incr\",\"key\":\"counter\",\"delta\":5}"
+	rawEventDecrCounter = "{\"type\":\"decr\",\"key\":\"counter\",\"delta\":5}"
+	rawEventAppendEU    = "{\"type\":\"append\",\"key\":\"regions\",\"value\":\"eu\"}"
+	rawEventRemoveEU    = "{\"type\":\"remove\",\"key\":\"regions\",\"value\":\"eu\"}"
+	rawEventSetMetadata = "{\"type\":\"set\",\"key\":\"key\",\"value\":\"value\",\"metadata\":{\"user\":\"Jane Doe\",\"message\":\"Release\"}}"
+	rawEventCheckpoint  = "{\"type\":\"checkpoint\",\"key\":\"\",\"snapshot\":[{\"key\":\"foo\",\"value\":\"bar\",\"commit\":\"abc\"}]}"
+	rawEventSetUnknown  = "{\"type\":\"set\",\"key\":\"key\",\"value\":\"value\",\"unknownField\":true}"
 
 	// Incorrect events
 	rawEventTypeUnknown           = "{\"type\":\"unknown\"}"
 	rawEventSetFooMissingValue    = "{\"type\":\"set\",\"key\":\"foo\"}"
 	rawEventSetMissingKeyValueBar = "{\"type\":\"set\",\"value\":\"bar\"}"
 	rawEventUnsetMissingKey       = "{\"type\":\"unset\"}"
+	rawEventSetUnknownValueType   = "{\"type\":\"set\",\"key\":\"foo\",\"value\":\"bar\",\"valueType\":\"float\"}"
+	rawEventIncrMissingDelta      = "{\"type\":\"incr\",\"key\":\"counter\"}"
+	rawEventDecrMissingKey        = "{\"type\":\"decr\",\"delta\":5}"
+	rawEventCheckpointMissingSnap = "{\"type\":\"checkpoint\",\"key\":\"\"}"
+	rawEventAppendMissingValue    = "{\"type\":\"append\",\"key\":\"regions\"}"
 )
 
 func wrapEvents(events ...string) string {
-	return fmt.Sprintf("{\"events\":[%v]}\n", strings.Join(events, ","))
+	return fmt.Sprintf("{\"version\":2,\"events\":[%v]}\n", strings.Join(events, ","))
 }
 
 func TestMarshal(t *testing.T) {
@@ -56,6 +69,21 @@ func TestMarshal(t *testing.T) {
 			input:  &[]Event{TestDataSetKeyValue, TestDataUnsetKey},
 			wanted: wrapEvents(rawEventSetKeyValue, rawEventUnsetKey),
 		},
+		{
+			name:   "set counter=12 (int)",
+			input:  &[]Event{TestDataSetCounterInt12},
+			wanted: wrapEvents(rawEventSetCounter),
+		},
+		{
+			name:   "incr counter by 5, decr counter by 5",
+			input:  &[]Event{TestDataIncrCounter5, TestDataDecrCounter5},
+			wanted: wrapEvents(rawEventIncrCounter, rawEventDecrCounter),
+		},
+		{
+			name:   "append eu to regions, remove eu from regions",
+			input:  &[]Event{TestDataAppendRegionsEU, TestDataRemoveRegionsEU},
+			wanted: wrapEvents(rawEventAppendEU, rawEventRemoveEU),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -99,6 +127,31 @@ func TestUnMarshalInvalidFormat(t *testing.T) {
 			input:           wrapEvents(rawEventUnsetMissingKey),
 			wantedErrorType: &KeyMissing{},
 		},
+		{
+			name:            "Set with unknown value type",
+			input:           wrapEvents(rawEventSetUnknownValueType),
+			wantedErrorType: &UnknownValueType{},
+		},
+		{
+			name:            "Incr with missing delta",
+			input:           wrapEvents(rawEventIncrMissingDelta),
+			wantedErrorType: &DeltaMissing{},
+		},
+		{
+			name:            "Decr with missing key",
+			input:           wrapEvents(rawEventDecrMissingKey),
+			wantedErrorType: &KeyMissing{},
+		},
+		{
+			name:            "Checkpoint with missing snapshot",
+			input:           wrapEvents(rawEventCheckpointMissingSnap),
+			wantedErrorType: &SnapshotMissing{},
+		},
+		{
+			name:            "Append with missing value",
+			input:           wrapEvents(rawEventAppendMissingValue),
+			wantedErrorType: &ValueMissing{},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -150,6 +203,43 @@ func TestUnMarshal(t *testing.T) {
 			input:  wrapEvents(rawEventSetKeyValue, rawEventUnsetKey),
 			wanted: []Event{TestDataSetKeyValue, TestDataUnsetKey},
 		},
+		{
+			name:   "set counter=12 (int)",
+			input:  wrapEvents(rawEventSetCounter),
+			wanted: []Event{TestDataSetCounterInt12},
+		},
+		{
+			name:   "incr counter by 5, decr counter by 5",
+			input:  wrapEvents(rawEventIncrCounter, rawEventDecrCounter),
+			wanted: []Event{TestDataIncrCounter5, TestDataDecrCounter5},
+		},
+		{
+			name:   "append eu to regions, remove eu from regions",
+			input:  wrapEvents(rawEventAppendEU, rawEventRemoveEU),
+			wanted: []Event{TestDataAppendRegionsEU, TestDataRemoveRegionsEU},
+		},
+		{
+			name:  "set key=value with metadata",
+			input: wrapEvents(rawEventSetMetadata),
+			wanted: []Event{
+				{
+					EventType: Set,
+					Key:       TestDataKey,
+					Value:     &TestDataValue,
+					Metadata:  &Metadata{User: "Jane Doe", Message: "Release"},
+				},
+			},
+		},
+		{
+			name:   "checkpoint",
+			input:  wrapEvents(rawEventCheckpoint),
+			wanted: []Event{*NewCheckpointEvent([]SnapshotEntry{{Key: TestDataFoo, Value: TestDataBar, Commit: "abc"}})},
+		},
+		{
+			name:   "set key=value with unknown field",
+			input:  wrapEvents(rawEventSetUnknown),
+			wanted: []Event{TestDataSetKeyValue},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -162,3 +252,55 @@ func TestUnMarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		wanted        []Event
+		wantedVersion int
+	}{
+		{
+			name:          "legacy",
+			input:         `{"key":"value","foo":"bar"}`,
+			wanted:        []Event{*NewCheckpointEvent([]SnapshotEntry{{Key: TestDataFoo, Value: TestDataBar}, {Key: TestDataKey, Value: TestDataValue}})},
+			wantedVersion: LegacyVersion,
+		},
+		{
+			name:          "legacy with non-string value",
+			input:         `{"counter":12}`,
+			wanted:        []Event{*NewCheckpointEvent([]SnapshotEntry{{Key: TestDataCounter, Value: TestData12}})},
+			wantedVersion: LegacyVersion,
+		},
+		{
+			name:          "events without version",
+			input:         "{\"events\":[" + rawEventSetFooBar + "]}",
+			wanted:        []Event{TestDataSetFooBar},
+			wantedVersion: 1,
+		},
+		{
+			name:          "current version",
+			input:         wrapEvents(rawEventSetFooBar),
+			wanted:        []Event{TestDataSetFooBar},
+			wantedVersion: CurrentVersion,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotVersion, err := Decode(tc.input)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wanted, got)
+			assert.Equal(t, tc.wantedVersion, gotVersion)
+		})
+	}
+}
+
+func TestDecodeUnsupportedVersion(t *testing.T) {
+	_, _, err := Decode(`{"version":99,"events":[]}`)
+
+	if assert.Error(t, err) {
+		assert.IsType(t, &UnsupportedVersion{}, err)
+	}
+}
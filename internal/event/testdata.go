@@ -12,6 +12,16 @@ var (
 	TestDataValue = "value"
 	// TestDataOtherValue
 	TestDataOtherValue = "otherValue"
+	// TestDataCounter
+	TestDataCounter = "counter"
+	// TestData12
+	TestData12 = "12"
+	// TestDataRegions
+	TestDataRegions = "regions"
+	// TestDataEU
+	TestDataEU = "eu"
+	// TestDataUS
+	TestDataUS = "us"
 )
 
 // TestDataSetFooBar is an event which sets foo=bar
@@ -35,6 +45,52 @@ var TestDataSetKeyOtherValue = Event{
 	Value:     &TestDataOtherValue,
 }
 
+// TestDataSetCounterInt12 is an event which sets counter=12 with value type int
+var TestDataSetCounterInt12 = Event{
+	EventType: Set,
+	Key:       TestDataCounter,
+	Value:     &TestData12,
+	ValueType: Int,
+}
+
+// TestDataDelta5 is the delta used by the counter events
+var TestDataDelta5 int64 = 5
+
+// TestDataIncrCounter5 is an event which increments counter by 5
+var TestDataIncrCounter5 = Event{
+	EventType: Incr,
+	Key:       TestDataCounter,
+	Delta:     &TestDataDelta5,
+}
+
+// TestDataDecrCounter5 is an event which decrements counter by 5
+var TestDataDecrCounter5 = Event{
+	EventType: Decr,
+	Key:       TestDataCounter,
+	Delta:     &TestDataDelta5,
+}
+
+// TestDataAppendRegionsEU is an event which appends eu to regions
+var TestDataAppendRegionsEU = Event{
+	EventType: Append,
+	Key:       TestDataRegions,
+	Value:     &TestDataEU,
+}
+
+// TestDataAppendRegionsUS is an event which appends us to regions
+var TestDataAppendRegionsUS = Event{
+	EventType: Append,
+	Key:       TestDataRegions,
+	Value:     &TestDataUS,
+}
+
+// TestDataRemoveRegionsEU is an event which removes eu from regions
+var TestDataRemoveRegionsEU = Event{
+	EventType: Remove,
+	Key:       TestDataRegions,
+	Value:     &TestDataEU,
+}
+
 // TestDataUnsetKey is an event which unsets key
 var TestDataUnsetKey = Event{
 	EventType: Unset,
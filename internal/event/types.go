@@ -1,8 +1,40 @@
 package event
 
+import "time"
+
 // Event represents an event stored in git notes
 type Event struct {
-	EventType Type    `json:"type"`
-	Key       string  `json:"key"`
-	Value     *string `json:"value,omitempty"`
+	EventType Type             `json:"type"`
+	Key       string           `json:"key"`
+	Value     *string          `json:"value,omitempty"`
+	ValueType ValueType        `json:"valueType,omitempty"`
+	Delta     *int64           `json:"delta,omitempty"`
+	ExpiresAt *time.Time       `json:"expiresAt,omitempty"`
+	Metadata  *Metadata        `json:"metadata,omitempty"`
+	Snapshot  *[]SnapshotEntry `json:"snapshot,omitempty"`
+
+	// Commit holds the hash of the commit whose note the event was read from. It is not stored in the note itself
+	Commit string `json:"-"`
+}
+
+// Metadata holds optional information on the origin of an event
+type Metadata struct {
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	User      string     `json:"user,omitempty"`
+	Hostname  string     `json:"hostname,omitempty"`
+	CIJobURL  string     `json:"ciJobUrl,omitempty"`
+	Message   string     `json:"message,omitempty"`
+}
+
+// SnapshotEntry holds the value of a single key in a checkpoint event, including everything needed to replay
+// events on top of it. An unset key is kept as well, so older events of commits which aren't ancestors of the
+// checkpoint can't bring it back
+type SnapshotEntry struct {
+	Key       string     `json:"key"`
+	Unset     bool       `json:"unset,omitempty"`
+	Value     string     `json:"value"`
+	ValueType ValueType  `json:"valueType,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Commit    string     `json:"commit,omitempty"` // Hash of the commit the key was last modified in
+	Metadata  *Metadata  `json:"metadata,omitempty"`
 }
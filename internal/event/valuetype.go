@@ -0,0 +1,145 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// ValueType represents the type of the value of a set event
+type ValueType int
+
+const (
+	// String represents a plain string value. This is the default for events without value type
+	String ValueType = iota
+	// Int represents an integer value
+	Int
+	// Bool represents a boolean value
+	Bool
+	// JSON represents an arbitrary JSON document
+	JSON
+	// List represents a list of strings, stored as JSON array
+	List
+)
+
+func (t ValueType) String() string {
+	return valueTypeToString[t]
+}
+
+var valueTypeToString = map[ValueType]string{
+	String: "string",
+	Int:    "int",
+	Bool:   "bool",
+	JSON:   "json",
+	List:   "list",
+}
+
+var valueTypeToID = map[string]ValueType{
+	"string": String,
+	"int":    Int,
+	"bool":   Bool,
+	"json":   JSON,
+	"list":   List,
+}
+
+// ParseValueType returns the value type for the provided name
+func ParseValueType(s string) (ValueType, error) {
+	t, ok := valueTypeToID[s]
+	if !ok {
+		return String, &UnknownValueType{ValueType: s}
+	}
+
+	return t, nil
+}
+
+// MarshalJSON marshals the enum as a quoted json string
+func (t ValueType) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString(`"`)
+	buffer.WriteString(valueTypeToString[t])
+	buffer.WriteString(`"`)
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalJSON unmashals a quoted json string to the enum value
+func (t *ValueType) UnmarshalJSON(b []byte) error {
+	var j string
+
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+
+	var err error
+	*t, err = ParseValueType(j)
+	return err
+}
+
+// NormalizeValue validates the value against the value type, and returns it in its canonical form
+func NormalizeValue(value string, t ValueType) (string, error) {
+	switch t {
+	case Int:
+		i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return "", &InvalidValue{value: value, valueType: t}
+		}
+		return strconv.FormatInt(i, 10), nil
+
+	case Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", &InvalidValue{value: value, valueType: t}
+		}
+		return strconv.FormatBool(b), nil
+
+	case JSON:
+		buffer := new(bytes.Buffer)
+		if err := json.Compact(buffer, []byte(value)); err != nil {
+			return "", &InvalidValue{value: value, valueType: t}
+		}
+		return buffer.String(), nil
+
+	case List:
+		items, err := parseList(value)
+		if err != nil {
+			return "", &InvalidValue{value: value, valueType: t}
+		}
+		return MarshalList(items), nil
+
+	default:
+		return value, nil
+	}
+}
+
+// ParseList returns the items of a value of type List
+func ParseList(value string) ([]string, error) {
+	items := []string{}
+	if err := json.Unmarshal([]byte(value), &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// parseList accepts either a JSON array of strings, or a comma-separated list of items
+func parseList(value string) ([]string, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		return ParseList(value)
+	}
+
+	items := []string{}
+	if value == "" {
+		return items, nil
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+
+	return items, nil
+}
+
+// MarshalList returns the canonical value of type List for the provided items
+func MarshalList(items []string) string {
+	result, _ := json.Marshal(items) // Marshalling a slice of strings cannot fail
+	return string(result)
+}
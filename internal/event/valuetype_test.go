@@ -0,0 +1,123 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	testCases := []struct {
+		name      string
+		value     string
+		valueType ValueType
+		wanted    string
+		valid     bool
+	}{
+		{
+			name:      "Any string is a valid string",
+			value:     " foo ",
+			valueType: String,
+			wanted:    " foo ",
+			valid:     true,
+		},
+		{
+			name:      "Valid int",
+			value:     "+42",
+			valueType: Int,
+			wanted:    "42",
+			valid:     true,
+		},
+		{
+			name:      "Invalid int",
+			value:     "3.14",
+			valueType: Int,
+			valid:     false,
+		},
+		{
+			name:      "Valid bool",
+			value:     "TRUE",
+			valueType: Bool,
+			wanted:    "true",
+			valid:     true,
+		},
+		{
+			name:      "Invalid bool",
+			value:     "yes",
+			valueType: Bool,
+			valid:     false,
+		},
+		{
+			name:      "Valid json",
+			value:     `{"foo": [1, 2]}`,
+			valueType: JSON,
+			wanted:    `{"foo":[1,2]}`,
+			valid:     true,
+		},
+		{
+			name:      "Invalid json",
+			value:     `{"foo": `,
+			valueType: JSON,
+			valid:     false,
+		},
+		{
+			name:      "Comma-separated list",
+			value:     "eu-west, us-east",
+			valueType: List,
+			wanted:    `["eu-west","us-east"]`,
+			valid:     true,
+		},
+		{
+			name:      "Empty list",
+			value:     "",
+			valueType: List,
+			wanted:    `[]`,
+			valid:     true,
+		},
+		{
+			name:      "JSON array list",
+			value:     `["a,b", "c"]`,
+			valueType: List,
+			wanted:    `["a,b","c"]`,
+			valid:     true,
+		},
+		{
+			name:      "Invalid JSON array list",
+			value:     `[1, 2]`,
+			valueType: List,
+			valid:     false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NormalizeValue(tc.value, tc.valueType)
+
+			if tc.valid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wanted, got)
+			} else {
+				if assert.Error(t, err) {
+					assert.IsType(t, &InvalidValue{}, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseValueType(t *testing.T) {
+	t.Run("Known value type", func(t *testing.T) {
+		got, err := ParseValueType("json")
+
+		assert.NoError(t, err)
+		assert.Equal(t, JSON, got)
+	})
+
+	t.Run("Unknown value type", func(t *testing.T) {
+		_, err := ParseValueType("float")
+
+		if assert.Error(t, err) {
+			assert.IsType(t, &UnknownValueType{}, err)
+		}
+	})
+}
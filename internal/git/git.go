@@ -1,8 +1,15 @@
 package git
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
 
+	"github.com/ldez/go-git-cmd-wrapper/v2/config"
 	"github.com/ldez/go-git-cmd-wrapper/v2/fetch"
 	gitCmdWrapper "github.com/ldez/go-git-cmd-wrapper/v2/git"
 	"github.com/ldez/go-git-cmd-wrapper/v2/notes"
@@ -15,26 +22,84 @@ import (
 type GoGitCmdWrapper struct {
 }
 
-// FetchNotes notes
-func (GoGitCmdWrapper) FetchNotes(notesRef string, force bool) (string, error) {
+// CommitInfo returns hash, author date and subject of the commit separated by tabs
+func (GoGitCmdWrapper) CommitInfo(hash string) (string, error) {
+	return gitCmdWrapper.Raw("log", func(g *types.Cmd) {
+		g.AddOptions("-1")
+		g.AddOptions("--pretty=format:%H%x09%aI%x09%s")
+		g.AddOptions("--end-of-options") // Never take a rev like --output=<file> as option
+		g.AddOptions(hash)
+		g.AddOptions("--")
+	})
+}
+
+// ConfigGet returns the value of the git config key, or error if it's not set
+func (GoGitCmdWrapper) ConfigGet(key string) (string, error) {
+	return gitCmdWrapper.Config(config.Get(key, ""))
+}
+
+// DeleteNotesRef deletes the notes reference
+func (GoGitCmdWrapper) DeleteNotesRef(notesRef string) (string, error) {
+	return gitCmdWrapper.Raw("update-ref", func(g *types.Cmd) {
+		g.AddOptions("-d")
+		g.AddOptions("refs/notes/" + notesRef)
+	})
+}
+
+// FetchNotes notes from the remote
+func (GoGitCmdWrapper) FetchNotes(remote string, notesRef string, force bool) (string, error) {
 	refSpec := fmt.Sprintf("refs/notes/%v:refs/notes/%v", notesRef, notesRef)
 	if force {
 		// Add + to force fetch
 		refSpec = fmt.Sprintf("+%v", refSpec)
 	}
-	return gitCmdWrapper.Fetch(fetch.NoTags, fetch.Remote("origin"), fetch.RefSpec(refSpec))
+	return gitCmdWrapper.Fetch(fetch.NoTags, fetch.Remote(remote), fetch.RefSpec(refSpec))
 }
 
-// LogCommits returns log output with commit hashes
-func (GoGitCmdWrapper) LogCommits() (string, error) {
-	return gitCmdWrapper.Raw("log", func(g *types.Cmd) {
-		g.AddOptions("--pretty=format:%H")
-	})
+// FetchNotesInto force-fetches the notes from the remote into another local notes reference
+func (GoGitCmdWrapper) FetchNotesInto(remote string, notesRef string, localNotesRef string) (string, error) {
+	refSpec := fmt.Sprintf("+refs/notes/%v:refs/notes/%v", notesRef, localNotesRef)
+	return gitCmdWrapper.Fetch(fetch.NoTags, fetch.Remote(remote), fetch.RefSpec(refSpec))
 }
 
-// NotesAdd sets/overwrites a note
-func (GoGitCmdWrapper) NotesAdd(notesRef, msg string) (string, error) {
-	return gitCmdWrapper.Notes(notes.Ref(notesRef), notes.Add("", notes.Message(msg), notes.Force))
+// LogCommitsEach streams the hashes of the commits reachable from rev, but not from any of the excluded commits, to
+// fn, newest first, until fn returns false. Commits beyond that point are never listed
+func (GoGitCmdWrapper) LogCommitsEach(rev string, exclude []string, fn func(hash string) bool) (string, error) {
+	args := []string{"log", "--pretty=format:%H", "--end-of-options", rev} // Never take a rev as option
+	for _, e := range exclude {
+		args = append(args, "^"+e)
+	}
+	cmd := exec.Command("git", append(args, "--")...)
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if !fn(scanner.Text()) {
+			// No need to list the remaining commits
+			cmd.Process.Kill()
+			cmd.Wait()
+			return "", nil
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return stderr.String(), err
+	}
+
+	return "", scanner.Err()
+}
+
+// NotesAdd sets/overwrites the note on the provided hash
+func (GoGitCmdWrapper) NotesAdd(notesRef, hash, msg string) (string, error) {
+	return gitCmdWrapper.Notes(notes.Ref(notesRef), notes.Add(hash, notes.Message(msg), notes.Force))
 }
 
 // NotesList returns all the notes
@@ -42,6 +107,12 @@ func (GoGitCmdWrapper) NotesList(notesRef string) (string, error) {
 	return gitCmdWrapper.Notes(notes.Ref(notesRef), notes.List(""))
 }
 
+// NotesMerge merges the other notes reference into the notes reference. Conflicting notes are resolved in favor of
+// the notes reference
+func (GoGitCmdWrapper) NotesMerge(notesRef, otherNotesRef string) (string, error) {
+	return gitCmdWrapper.Notes(notes.Ref(notesRef), notes.Merge(notes.Quiet, notes.Strategy("ours"), notes.NotesRef("refs/notes/"+otherNotesRef)))
+}
+
 // NotesPrune prunes unreachable notes
 func (GoGitCmdWrapper) NotesPrune(notesRef string) (string, error) {
 	return gitCmdWrapper.Notes(notes.Ref(notesRef), notes.Prune())
@@ -52,13 +123,76 @@ func (GoGitCmdWrapper) NotesShow(notesRef, hash string) (string, error) {
 	return gitCmdWrapper.Notes(notes.Ref(notesRef), notes.Show(hash))
 }
 
-// PushNotes notes
-func (GoGitCmdWrapper) PushNotes(notesRef string) (string, error) {
+// PushNotes notes to the remote
+func (GoGitCmdWrapper) PushNotes(remote string, notesRef string) (string, error) {
 	refSpec := fmt.Sprintf("refs/notes/%v:refs/notes/%v", notesRef, notesRef)
-	return gitCmdWrapper.Push(push.Remote("origin"), push.RefSpec(refSpec))
+	return gitCmdWrapper.Push(push.Remote(remote), push.RefSpec(refSpec))
+}
+
+// ReadBlobs calls fn with a function returning the contents of a blob. Until fn returns, all blobs are read through
+// a single git cat-file --batch process
+func (GoGitCmdWrapper) ReadBlobs(fn func(readBlob func(hash string) (string, error)) error) error {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	defer func() {
+		stdin.Close()
+		cmd.Wait()
+	}()
+
+	reader := bufio.NewReader(stdout)
+	return fn(func(hash string) (string, error) {
+		return readBlob(stdin, reader, hash)
+	})
+}
+
+// readBlob requests a single blob from a running git cat-file --batch process, and reads its contents
+func readBlob(stdin io.Writer, stdout *bufio.Reader, blob string) (string, error) {
+	if _, err := fmt.Fprintln(stdin, blob); err != nil {
+		return "", err
+	}
+
+	// Header is "<hash> <type> <size>", or "<hash> missing"
+	header, err := stdout.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return "", fmt.Errorf("cannot read blob %v: %v", blob, strings.TrimSpace(header))
+	}
+
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return "", err
+	}
+
+	contents := make([]byte, size+1) // Contents are followed by a newline
+	if _, err := io.ReadFull(stdout, contents); err != nil {
+		return "", err
+	}
+
+	return string(contents[:size]), nil
 }
 
-// RevParseHead returns the HEAD commit hash
-func (g GoGitCmdWrapper) RevParseHead() (string, error) {
-	return gitCmdWrapper.RevParse(revparse.Args("HEAD"))
+// RevParse returns the commit hash the provided rev points to
+func (g GoGitCmdWrapper) RevParse(rev string) (string, error) {
+	return gitCmdWrapper.RevParse(revparse.Verify, revparse.Args(fmt.Sprintf("%v^{commit}", rev)))
+}
+
+// UpdateNotesRef points the notes reference at the notes commit
+func (GoGitCmdWrapper) UpdateNotesRef(notesRef, hash string) (string, error) {
+	return gitCmdWrapper.Raw("update-ref", func(g *types.Cmd) {
+		g.AddOptions("refs/notes/" + notesRef)
+		g.AddOptions(hash)
+	})
 }
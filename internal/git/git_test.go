@@ -0,0 +1,178 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testNotesRef = "gino_keva"
+
+// newTestRepo generates a repository with a note on each commit, and changes the working directory into it.
+// The commit hashes are returned newest first
+func newTestRepo(tb testing.TB, numberOfCommits int) []string {
+	if _, err := exec.LookPath("git"); err != nil {
+		tb.Skip("git not available")
+	}
+
+	dir := tb.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			tb.Fatalf("git %v: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	git("init", "-q")
+	git("config", "user.name", "Test")
+	git("config", "user.email", "test@example.com")
+
+	hashes := []string{}
+	for i := 0; i < numberOfCommits; i++ {
+		git("commit", "-q", "--allow-empty", "-m", fmt.Sprintf("Commit %d", i))
+		note := fmt.Sprintf(`{"version":2,"events":[{"type":"set","key":"key%d","value":"value%d"}]}`, i, i)
+		git("notes", "--ref", testNotesRef, "add", "-m", note)
+		hashes = append([]string{git("rev-parse", "HEAD")}, hashes...)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.Chdir(wd) })
+
+	return hashes
+}
+
+// newTestBlobs returns the note blob of each of the hashes
+func newTestBlobs(tb testing.TB, hashes []string) []string {
+	out, err := GoGitCmdWrapper{}.NotesList(testNotesRef)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	blobs := map[string]string{} // Commit hash -> note blob hash
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		blobs[fields[1]] = fields[0]
+	}
+
+	result := []string{}
+	for _, hash := range hashes {
+		result = append(result, blobs[hash])
+	}
+	return result
+}
+
+func TestReadBlobs(t *testing.T) {
+	hashes := newTestRepo(t, 5)
+	blobs := newTestBlobs(t, hashes)
+	g := GoGitCmdWrapper{}
+
+	err := g.ReadBlobs(func(readBlob func(hash string) (string, error)) error {
+		for i, blob := range blobs {
+			wanted, err := g.NotesShow(testNotesRef, hashes[i])
+			assert.NoError(t, err)
+
+			got, err := readBlob(blob)
+			assert.NoError(t, err)
+			assert.Equal(t, wanted, got)
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestReadBlobsMissingBlob(t *testing.T) {
+	newTestRepo(t, 1)
+	g := GoGitCmdWrapper{}
+
+	err := g.ReadBlobs(func(readBlob func(hash string) (string, error)) error {
+		_, err := readBlob("0000000000000000000000000000000000000000")
+		return err
+	})
+
+	assert.Error(t, err)
+}
+
+const benchmarkNumberOfNotes = 200
+
+func BenchmarkNotesShow(b *testing.B) {
+	hashes := newTestRepo(b, benchmarkNumberOfNotes)
+	g := GoGitCmdWrapper{}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, hash := range hashes {
+			if _, err := g.NotesShow(testNotesRef, hash); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkReadBlobs(b *testing.B) {
+	blobs := newTestBlobs(b, newTestRepo(b, benchmarkNumberOfNotes))
+	g := GoGitCmdWrapper{}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := g.ReadBlobs(func(readBlob func(hash string) (string, error)) error {
+			for _, blob := range blobs {
+				if _, err := readBlob(blob); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRevLooksLikeOption(t *testing.T) {
+	newTestRepo(t, 1)
+	output := t.TempDir() + "/output"
+	rev := "--output=" + output
+
+	testCases := []struct {
+		name string
+		call func() (string, error)
+	}{
+		{
+			name: "CommitInfo",
+			call: func() (string, error) { return GoGitCmdWrapper{}.CommitInfo(rev) },
+		},
+		{
+			name: "LogCommitsEach",
+			call: func() (string, error) {
+				return GoGitCmdWrapper{}.LogCommitsEach(rev, nil, func(string) bool { return true })
+			},
+		},
+		{
+			name: "RevParse",
+			call: func() (string, error) { return GoGitCmdWrapper{}.RevParse(rev) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.call()
+
+			assert.Error(t, err)
+			assert.NoFileExists(t, output)
+		})
+	}
+}
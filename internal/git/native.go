@@ -0,0 +1,731 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+const (
+	notesAddMessage   = "Notes added by 'git notes add'\n"
+	notesPruneMessage = "Notes removed by 'git notes prune'\n"
+)
+
+// GoGitNativeWrapper implements the Wrapper interface using go-git, so without the git binary. Output and error
+// messages mimic those of the git CLI, so both implementations can be used interchangeably
+type GoGitNativeWrapper struct {
+	repo *gogit.Repository
+}
+
+// repository opens the repository the working directory is in, once
+func (g *GoGitNativeWrapper) repository() (*gogit.Repository, error) {
+	if g.repo != nil {
+		return g.repo, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := gogit.PlainOpenWithOptions(wd, &gogit.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if errors.Is(err, gogit.ErrRepositoryNotExists) {
+		return nil, errors.New("fatal: not a git repository (or any of the parent directories): .git")
+	} else if err != nil {
+		return nil, err
+	}
+
+	g.repo = repo
+	return g.repo, nil
+}
+
+// CommitInfo returns hash, author date and subject of the commit separated by tabs
+func (g *GoGitNativeWrapper) CommitInfo(hash string) (string, error) {
+	commit, out, err := g.commit(hash)
+	if err != nil {
+		return out, err
+	}
+
+	date := commit.Author.When.Format("2006-01-02T15:04:05-07:00") // Strict ISO 8601, like %aI
+	return fmt.Sprintf("%v\t%v\t%v", commit.Hash, date, subject(commit.Message)), nil
+}
+
+// subject returns the first paragraph of the commit message joined into a single line, like %s
+func subject(message string) string {
+	paragraph := strings.SplitN(strings.TrimLeft(message, "\n"), "\n\n", 2)[0]
+	return strings.Join(strings.Fields(strings.ReplaceAll(paragraph, "\n", " ")), " ")
+}
+
+// ConfigGet returns the value of the git config key, or error if it's not set
+func (g *GoGitNativeWrapper) ConfigGet(key string) (string, error) {
+	repo, err := g.repository()
+	if err != nil {
+		return err.Error(), err
+	}
+
+	cfg, err := repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	i, j := strings.Index(key, "."), strings.LastIndex(key, ".")
+	if i < 0 {
+		err = fmt.Errorf("error: key does not contain a section: %v", key)
+		return err.Error(), err
+	}
+
+	section, name := cfg.Raw.Section(key[:i]), key[j+1:]
+	if i == j && section.HasOption(name) {
+		return section.Option(name) + "\n", nil
+	}
+	if i < j && section.Subsection(key[i+1:j]).HasOption(name) {
+		return section.Subsection(key[i+1:j]).Option(name) + "\n", nil
+	}
+
+	// Like git, fail without output if the key isn't set
+	return "", errors.New("exit status 1")
+}
+
+// DeleteNotesRef deletes the notes reference
+func (g *GoGitNativeWrapper) DeleteNotesRef(notesRef string) (string, error) {
+	repo, err := g.repository()
+	if err != nil {
+		return err.Error(), err
+	}
+
+	err = repo.Storer.RemoveReference(plumbing.ReferenceName("refs/notes/" + notesRef))
+	if err != nil {
+		return err.Error(), err
+	}
+	return "", nil
+}
+
+// FetchNotes notes from the remote. Since go-git only refuses non-fast-forward updates of branches, the notes are fetched into a
+// temporary reference first, and only then checked and applied
+func (g *GoGitNativeWrapper) FetchNotes(remote string, notesRef string, force bool) (string, error) {
+	repo, err := g.repository()
+	if err != nil {
+		return err.Error(), err
+	}
+
+	localName := plumbing.ReferenceName("refs/notes/" + notesRef)
+	fetchedName := plumbing.ReferenceName("refs/gino-keva/fetched/" + notesRef)
+	defer repo.Storer.RemoveReference(fetchedName)
+
+	err = repo.Fetch(&gogit.FetchOptions{
+		RemoteName: remote,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+%v:%v", localName, fetchedName))},
+		Tags:       gogit.NoTags,
+	})
+	if errors.Is(err, gogit.NoMatchingRefSpecError{}) {
+		return fmt.Sprintf("fatal: couldn't find remote ref %v\n", localName), err
+	} else if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return err.Error(), err
+	}
+
+	fetched, err := repo.Reference(fetchedName, true)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	old, err := repo.Reference(localName, true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		old = nil
+	} else if err != nil {
+		return err.Error(), err
+	}
+
+	if old != nil && !force {
+		ff, err := g.isAncestor(old.Hash(), fetched.Hash())
+		if err != nil {
+			return err.Error(), err
+		}
+		if !ff {
+			err = errors.New("exit status 1")
+			return fmt.Sprintf(" ! [rejected] %v -> %v (non-fast-forward)\n", localName, localName), err
+		}
+	}
+
+	err = repo.Storer.SetReference(plumbing.NewHashReference(localName, fetched.Hash()))
+	if err != nil {
+		return err.Error(), err
+	}
+	return "", nil
+}
+
+// FetchNotesInto force-fetches the notes from the remote into another local notes reference
+func (g *GoGitNativeWrapper) FetchNotesInto(remote string, notesRef string, localNotesRef string) (string, error) {
+	repo, err := g.repository()
+	if err != nil {
+		return err.Error(), err
+	}
+
+	remoteName := plumbing.ReferenceName("refs/notes/" + notesRef)
+	err = repo.Fetch(&gogit.FetchOptions{
+		RemoteName: remote,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+%v:refs/notes/%v", remoteName, localNotesRef))},
+		Tags:       gogit.NoTags,
+	})
+	if errors.Is(err, gogit.NoMatchingRefSpecError{}) {
+		return fmt.Sprintf("fatal: couldn't find remote ref %v\n", remoteName), err
+	} else if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return err.Error(), err
+	}
+	return "", nil
+}
+
+// isAncestor returns whether commit a is an ancestor of (or equal to) commit b
+func (g *GoGitNativeWrapper) isAncestor(a, b plumbing.Hash) (bool, error) {
+	commit, err := g.repo.CommitObject(b)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	err = object.NewCommitPreorderIter(commit, nil, nil).ForEach(func(c *object.Commit) error {
+		if c.Hash == a {
+			found = true
+			return storer.ErrStop
+		}
+		return nil
+	})
+	return found, err
+}
+
+// LogCommitsEach streams the hashes of the commits reachable from rev, but not from any of the excluded commits, to
+// fn, newest first, until fn returns false. Commits beyond that point are never listed
+func (g *GoGitNativeWrapper) LogCommitsEach(rev string, exclude []string, fn func(hash string) bool) (string, error) {
+	commit, out, err := g.commit(rev)
+	if err != nil {
+		return out, err
+	}
+
+	excluded, out, err := g.ancestors(exclude)
+	if err != nil {
+		return out, err
+	}
+
+	iter, err := g.repo.Log(&gogit.LogOptions{From: commit.Hash, Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return err.Error(), err
+	}
+	defer iter.Close()
+
+	err = iter.ForEach(func(c *object.Commit) error {
+		if excluded[c.Hash] {
+			return nil
+		}
+		if !fn(c.Hash.String()) {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return err.Error(), err
+	}
+
+	return "", nil
+}
+
+// ancestors returns the commits reachable from any of the revs
+func (g *GoGitNativeWrapper) ancestors(revs []string) (map[plumbing.Hash]bool, string, error) {
+	ancestors := map[plumbing.Hash]bool{}
+	for _, rev := range revs {
+		commit, out, err := g.commit(rev)
+		if err != nil {
+			return nil, out, err
+		}
+
+		iter := object.NewCommitPreorderIter(commit, ancestors, nil)
+		err = iter.ForEach(func(c *object.Commit) error {
+			ancestors[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err.Error(), err
+		}
+	}
+
+	return ancestors, "", nil
+}
+
+// NotesAdd sets/overwrites the note on the provided hash
+func (g *GoGitNativeWrapper) NotesAdd(notesRef, hash, msg string) (string, error) {
+	commit, out, err := g.commit(hash)
+	if err != nil {
+		return out, err
+	}
+
+	notes, err := g.readNotes(notesRef)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	blob, err := g.storeBlob(msg)
+	if err != nil {
+		return err.Error(), err
+	}
+	notes[commit.Hash] = blob
+
+	err = g.writeNotes(notesRef, notes, notesAddMessage)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	return "", nil
+}
+
+// NotesList returns all the notes
+func (g *GoGitNativeWrapper) NotesList(notesRef string) (string, error) {
+	notes, err := g.readNotes(notesRef)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	lines := make([]string, 0, len(notes))
+	for commit, blob := range notes {
+		lines = append(lines, fmt.Sprintf("%v %v\n", blob, commit))
+	}
+	// Sort by commit hash, like git does
+	sort.Slice(lines, func(i, j int) bool { return lines[i][41:] < lines[j][41:] })
+
+	return strings.Join(lines, ""), nil
+}
+
+// NotesMerge merges the other notes reference into the notes reference. Like git notes merge with the ours strategy,
+// notes changed on both sides since the merge base are resolved in favor of the notes reference
+func (g *GoGitNativeWrapper) NotesMerge(notesRef, otherNotesRef string) (string, error) {
+	repo, err := g.repository()
+	if err != nil {
+		return err.Error(), err
+	}
+
+	localName := plumbing.ReferenceName("refs/notes/" + notesRef)
+	otherName := plumbing.ReferenceName("refs/notes/" + otherNotesRef)
+
+	other, err := repo.Reference(otherName, true)
+	if err != nil {
+		return fmt.Sprintf("fatal: failed to resolve '%v' as a valid ref.\n", otherName), err
+	}
+
+	local, err := repo.Reference(localName, true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		err = repo.Storer.SetReference(plumbing.NewHashReference(localName, other.Hash()))
+		if err != nil {
+			return err.Error(), err
+		}
+		return "", nil
+	} else if err != nil {
+		return err.Error(), err
+	}
+
+	upToDate, err := g.isAncestor(other.Hash(), local.Hash())
+	if err != nil {
+		return err.Error(), err
+	}
+	if upToDate {
+		return "", nil
+	}
+
+	fastForward, err := g.isAncestor(local.Hash(), other.Hash())
+	if err != nil {
+		return err.Error(), err
+	}
+	if fastForward {
+		err = repo.Storer.SetReference(plumbing.NewHashReference(localName, other.Hash()))
+		if err != nil {
+			return err.Error(), err
+		}
+		return "", nil
+	}
+
+	notes, err := g.mergeNotes(local.Hash(), other.Hash())
+	if err != nil {
+		return err.Error(), err
+	}
+
+	err = g.writeNotes(notesRef, notes, fmt.Sprintf("Merged notes from %v into %v\n", otherName, localName), other.Hash())
+	if err != nil {
+		return err.Error(), err
+	}
+	return "", nil
+}
+
+// mergeNotes returns the result of a three-way merge of the notes of both notes commits, resolving notes changed on
+// both sides in favor of ours
+func (g *GoGitNativeWrapper) mergeNotes(ours, theirs plumbing.Hash) (map[plumbing.Hash]plumbing.Hash, error) {
+	oursCommit, err := g.repo.CommitObject(ours)
+	if err != nil {
+		return nil, err
+	}
+	theirsCommit, err := g.repo.CommitObject(theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	baseNotes := map[plumbing.Hash]plumbing.Hash{}
+	bases, err := oursCommit.MergeBase(theirsCommit)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) > 0 {
+		if baseNotes, err = g.readNotesOf(bases[0]); err != nil {
+			return nil, err
+		}
+	}
+	ourNotes, err := g.readNotesOf(oursCommit)
+	if err != nil {
+		return nil, err
+	}
+	theirNotes, err := g.readNotesOf(theirsCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := map[plumbing.Hash]plumbing.Hash{}
+	for commit, blob := range ourNotes {
+		merged[commit] = blob
+	}
+	for commit := range baseNotes {
+		if _, ok := theirNotes[commit]; !ok && ourNotes[commit] == baseNotes[commit] {
+			delete(merged, commit) // Removed by them only
+		}
+	}
+	for commit, blob := range theirNotes {
+		base, inBase := baseNotes[commit]
+		ourBlob, inOurs := ourNotes[commit]
+		if (!inBase && !inOurs) || (inBase && inOurs && ourBlob == base) {
+			merged[commit] = blob // Added or changed by them only
+		}
+	}
+
+	return merged, nil
+}
+
+// NotesPrune prunes unreachable notes
+func (g *GoGitNativeWrapper) NotesPrune(notesRef string) (string, error) {
+	notes, err := g.readNotes(notesRef)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	pruned := false
+	for commit := range notes {
+		if _, err := g.repo.Storer.EncodedObject(plumbing.AnyObject, commit); errors.Is(err, plumbing.ErrObjectNotFound) {
+			delete(notes, commit)
+			pruned = true
+		} else if err != nil {
+			return err.Error(), err
+		}
+	}
+
+	if !pruned {
+		return "", nil
+	}
+
+	err = g.writeNotes(notesRef, notes, notesPruneMessage)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	return "", nil
+}
+
+// NotesShow returns the note for provided hash, or error if there is none
+func (g *GoGitNativeWrapper) NotesShow(notesRef, hash string) (string, error) {
+	commit, out, err := g.commit(hash)
+	if err != nil {
+		return out, err
+	}
+
+	notes, err := g.readNotes(notesRef)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	blob, ok := notes[commit.Hash]
+	if !ok {
+		err = fmt.Errorf("error: no note found for object %v.", commit.Hash)
+		return err.Error() + "\n", err
+	}
+
+	note, err := g.readBlob(blob)
+	if err != nil {
+		return err.Error(), err
+	}
+	return note, nil
+}
+
+// PushNotes notes to the remote
+func (g *GoGitNativeWrapper) PushNotes(remote string, notesRef string) (string, error) {
+	repo, err := g.repository()
+	if err != nil {
+		return err.Error(), err
+	}
+
+	refSpec := fmt.Sprintf("refs/notes/%v:refs/notes/%v", notesRef, notesRef)
+	err = repo.Push(&gogit.PushOptions{
+		RemoteName: remote,
+		RefSpecs:   []config.RefSpec{config.RefSpec(refSpec)},
+	})
+	switch {
+	case err == nil, errors.Is(err, gogit.NoErrAlreadyUpToDate):
+		return "", nil
+	case strings.Contains(err.Error(), "non-fast-forward update"):
+		return fmt.Sprintf(" ! [rejected] refs/notes/%v -> refs/notes/%v (fetch first)\n", notesRef, notesRef), err
+	default:
+		return err.Error(), err
+	}
+}
+
+// ReadBlobs calls fn with a function returning the contents of a blob
+func (g *GoGitNativeWrapper) ReadBlobs(fn func(readBlob func(hash string) (string, error)) error) error {
+	if _, err := g.repository(); err != nil {
+		return err
+	}
+
+	return fn(func(hash string) (string, error) {
+		return g.readBlob(plumbing.NewHash(hash))
+	})
+}
+
+// RevParse returns the commit hash the provided rev points to
+func (g *GoGitNativeWrapper) RevParse(rev string) (string, error) {
+	commit, out, err := g.commit(rev)
+	if err != nil {
+		return out, err
+	}
+
+	return commit.Hash.String() + "\n", nil
+}
+
+// UpdateNotesRef points the notes reference at the notes commit
+func (g *GoGitNativeWrapper) UpdateNotesRef(notesRef, hash string) (string, error) {
+	repo, err := g.repository()
+	if err != nil {
+		return err.Error(), err
+	}
+
+	if _, err := repo.CommitObject(plumbing.NewHash(hash)); err != nil {
+		return fmt.Sprintf("fatal: %v: not a valid SHA1\n", hash), err
+	}
+
+	err = repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName("refs/notes/"+notesRef), plumbing.NewHash(hash)))
+	if err != nil {
+		return err.Error(), err
+	}
+	return "", nil
+}
+
+// commit resolves rev into a commit. On error, the git-like output is returned as well
+func (g *GoGitNativeWrapper) commit(rev string) (*object.Commit, string, error) {
+	repo, err := g.repository()
+	if err != nil {
+		return nil, err.Error(), err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		err = fmt.Errorf("fatal: ambiguous argument '%v': unknown revision or path not in the working tree.", rev)
+		return nil, err.Error() + "\n", err
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err.Error(), err
+	}
+	return commit, "", nil
+}
+
+// readNotes returns the note blob of each commit in the notes ref. Notes stored in fan-out directories (as git does
+// for large numbers of notes) are read as well
+func (g *GoGitNativeWrapper) readNotes(notesRef string) (map[plumbing.Hash]plumbing.Hash, error) {
+	if _, err := g.repository(); err != nil {
+		return nil, err
+	}
+
+	tree, err := g.notesTree(notesRef)
+	if err != nil || tree == nil {
+		return map[plumbing.Hash]plumbing.Hash{}, err
+	}
+
+	return readNotesTree(tree)
+}
+
+// readNotesOf returns the note blob of each commit in the tree of the notes commit
+func (g *GoGitNativeWrapper) readNotesOf(notesCommit *object.Commit) (map[plumbing.Hash]plumbing.Hash, error) {
+	tree, err := notesCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	return readNotesTree(tree)
+}
+
+func readNotesTree(tree *object.Tree) (map[plumbing.Hash]plumbing.Hash, error) {
+	notes := map[plumbing.Hash]plumbing.Hash{}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		commit := strings.ReplaceAll(name, "/", "")
+		if !entry.Mode.IsFile() || !plumbing.IsHash(commit) {
+			continue
+		}
+		notes[plumbing.NewHash(commit)] = entry.Hash
+	}
+
+	return notes, nil
+}
+
+// notesTree returns the tree of the notes ref, or nil if the notes ref doesn't exist yet
+func (g *GoGitNativeWrapper) notesTree(notesRef string) (*object.Tree, error) {
+	ref, err := g.repo.Reference(plumbing.ReferenceName("refs/notes/"+notesRef), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	commit, err := g.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// writeNotes commits a new tree holding the notes onto the notes ref, with the merged commits as additional parents.
+// Notes are written without fan-out
+func (g *GoGitNativeWrapper) writeNotes(notesRef string, notes map[plumbing.Hash]plumbing.Hash, message string, merged ...plumbing.Hash) error {
+	tree := &object.Tree{}
+	for commit, blob := range notes {
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: commit.String(), Mode: filemode.Regular, Hash: blob})
+	}
+	sort.Slice(tree.Entries, func(i, j int) bool { return tree.Entries[i].Name < tree.Entries[j].Name })
+
+	treeHash, err := g.storeObject(tree)
+	if err != nil {
+		return err
+	}
+
+	refName := plumbing.ReferenceName("refs/notes/" + notesRef)
+	var parents []plumbing.Hash
+	oldRef, err := g.repo.Reference(refName, true)
+	if err == nil {
+		parents = append(parents, oldRef.Hash())
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return err
+	}
+	parents = append(parents, merged...)
+
+	signature, err := g.signature()
+	if err != nil {
+		return err
+	}
+
+	commitHash, err := g.storeObject(&object.Commit{
+		Author:       *signature,
+		Committer:    *signature,
+		Message:      message,
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Fail if the notes ref was changed in the meanwhile, rather than silently discarding that change
+	return g.repo.Storer.CheckAndSetReference(plumbing.NewHashReference(refName, commitHash), oldRef)
+}
+
+// signature returns the identity to write notes with, from the environment or git config like git does
+func (g *GoGitNativeWrapper) signature() (*object.Signature, error) {
+	name, email := os.Getenv("GIT_COMMITTER_NAME"), os.Getenv("GIT_COMMITTER_EMAIL")
+
+	if name == "" || email == "" {
+		cfg, err := g.repo.ConfigScoped(config.GlobalScope)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = cfg.User.Name
+		}
+		if email == "" {
+			email = cfg.User.Email
+		}
+	}
+
+	if name == "" || email == "" {
+		return nil, errors.New("Author identity unknown\n\n*** Please tell me who you are.")
+	}
+
+	return &object.Signature{Name: name, Email: email, When: time.Now()}, nil
+}
+
+func (g *GoGitNativeWrapper) storeBlob(contents string) (plumbing.Hash, error) {
+	obj := g.repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := w.Write([]byte(contents)); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return g.repo.Storer.SetEncodedObject(obj)
+}
+
+func (g *GoGitNativeWrapper) storeObject(o object.Object) (plumbing.Hash, error) {
+	obj := g.repo.Storer.NewEncodedObject()
+	if err := o.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return g.repo.Storer.SetEncodedObject(obj)
+}
+
+func (g *GoGitNativeWrapper) readBlob(hash plumbing.Hash) (string, error) {
+	blob, err := g.repo.BlobObject(hash)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := blob.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	var sb strings.Builder
+	if _, err := io.Copy(&sb, r); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,217 @@
+package git
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wrapper holds the methods both wrappers have in common, which are compared
+type wrapper interface {
+	CommitInfo(hash string) (string, error)
+	ConfigGet(key string) (string, error)
+	LogCommitsEach(rev string, exclude []string, fn func(hash string) bool) (string, error)
+	NotesList(notesRef string) (string, error)
+	NotesShow(notesRef, hash string) (string, error)
+	ReadBlobs(fn func(readBlob func(hash string) (string, error)) error) error
+	RevParse(rev string) (string, error)
+}
+
+// TestNativeOutputMatchesCLI verifies that the native wrapper returns the same output as the git CLI, since callers
+// parse the output of both in the same way
+func TestNativeOutputMatchesCLI(t *testing.T) {
+	hashes := newTestRepo(t, 3)
+	cli := &GoGitCmdWrapper{}
+	native := &GoGitNativeWrapper{}
+
+	testCases := []struct {
+		name string
+		call func(w wrapper) (string, error)
+	}{
+		{
+			name: "RevParse",
+			call: func(w wrapper) (string, error) { return w.RevParse("HEAD~1") },
+		},
+		{
+			name: "LogCommitsEach excluding ancestors",
+			call: func(w wrapper) (string, error) {
+				hashes := []string{}
+				out, err := w.LogCommitsEach("HEAD", []string{"HEAD~1"}, func(hash string) bool {
+					hashes = append(hashes, hash)
+					return true
+				})
+				return strings.Join(hashes, "\n") + out, err
+			},
+		},
+		{
+			name: "CommitInfo",
+			call: func(w wrapper) (string, error) { return w.CommitInfo(hashes[0]) },
+		},
+		{
+			name: "ConfigGet",
+			call: func(w wrapper) (string, error) { return w.ConfigGet("user.email") },
+		},
+		{
+			name: "NotesList",
+			call: func(w wrapper) (string, error) { return w.NotesList(testNotesRef) },
+		},
+		{
+			name: "NotesShow",
+			call: func(w wrapper) (string, error) { return w.NotesShow(testNotesRef, hashes[1]) },
+		},
+		{
+			name: "ReadBlobs",
+			call: func(w wrapper) (out string, err error) {
+				err = w.ReadBlobs(func(readBlob func(hash string) (string, error)) error {
+					out, err = readBlob(newTestBlobs(t, hashes)[1])
+					return err
+				})
+				return out, err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wanted, err := tc.call(cli)
+			assert.NoError(t, err)
+
+			got, err := tc.call(native)
+			assert.NoError(t, err)
+			assert.Equal(t, wanted, got)
+		})
+	}
+}
+
+func TestNativeErrorOutputMatchesCLI(t *testing.T) {
+	newTestRepo(t, 1)
+	runGit("notes", "--ref", testNotesRef, "remove", "HEAD")
+	cli := &GoGitCmdWrapper{}
+	native := &GoGitNativeWrapper{}
+
+	// Only the start of the output is compared, since that's what callers check
+	wanted, wantedErr := cli.NotesShow(testNotesRef, "HEAD")
+	got, err := native.NotesShow(testNotesRef, "HEAD")
+	assert.Error(t, wantedErr)
+	assert.Error(t, err)
+	assert.Equal(t, strings.SplitN(wanted, ".", 2)[0], strings.SplitN(got, ".", 2)[0])
+
+	_, wantedErr = cli.ConfigGet("gino-keva.missing")
+	got, err = native.ConfigGet("gino-keva.missing")
+	assert.Error(t, wantedErr)
+	assert.Error(t, err)
+	assert.Equal(t, "", got)
+}
+
+func TestNativeNotesAreReadableByCLI(t *testing.T) {
+	hashes := newTestRepo(t, 2)
+	native := &GoGitNativeWrapper{}
+
+	_, err := native.NotesAdd(testNotesRef, hashes[0], `{"version":2,"events":[]}`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{"version":2,"events":[]}`, runGit("notes", "--ref", testNotesRef, "show", hashes[0]))
+	assert.Equal(t, strings.TrimSpace(notesOf(t, hashes[1])), runGit("notes", "--ref", testNotesRef, "show", hashes[1]))
+	assert.Equal(t, "Notes added by 'git notes add'", runGit("log", "-1", "--format=%s", "refs/notes/"+testNotesRef))
+}
+
+func TestNativeReadsFanOutNotes(t *testing.T) {
+	hashes := newTestRepo(t, 2)
+
+	// Rewrite the notes tree with fan-out directories, like git does for large numbers of notes
+	runGit("read-tree", "--empty")
+	for _, hash := range hashes {
+		blob := runGit("rev-parse", "refs/notes/"+testNotesRef+":"+hash)
+		runGit("update-index", "--add", "--cacheinfo", "100644,"+blob+","+hash[:2]+"/"+hash[2:])
+	}
+	tree := runGit("write-tree")
+	commit := runGit("commit-tree", tree, "-p", "refs/notes/"+testNotesRef, "-m", "Fan-out")
+	runGit("update-ref", "refs/notes/"+testNotesRef, commit)
+	runGit("read-tree", "HEAD")
+
+	native := &GoGitNativeWrapper{}
+	wanted, err := (&GoGitCmdWrapper{}).NotesList(testNotesRef)
+	assert.NoError(t, err)
+
+	got, err := native.NotesList(testNotesRef)
+	assert.NoError(t, err)
+	assert.Equal(t, wanted, got)
+}
+
+func TestNativeNotesMergeMatchesCLI(t *testing.T) {
+	hashes := newTestRepo(t, 3)
+	base := runGit("rev-parse", "refs/notes/"+testNotesRef)
+
+	// Diverge the notes: both sides change the newest note, only theirs changes the oldest one and only ours
+	// removes the middle one
+	runGit("notes", "--ref", testNotesRef, "add", "-f", "-m", "ours", hashes[0])
+	runGit("notes", "--ref", testNotesRef, "remove", hashes[1])
+	runGit("update-ref", "refs/notes/theirs", base)
+	runGit("notes", "--ref", "theirs", "add", "-f", "-m", "theirs", hashes[0])
+	runGit("notes", "--ref", "theirs", "add", "-f", "-m", "theirs", hashes[2])
+
+	ours := runGit("rev-parse", "refs/notes/"+testNotesRef)
+	runGit("update-ref", "refs/notes/cli", ours)
+	runGit("update-ref", "refs/notes/native", ours)
+
+	cli := &GoGitCmdWrapper{}
+	native := &GoGitNativeWrapper{}
+	_, err := cli.NotesMerge("cli", "theirs")
+	assert.NoError(t, err)
+	_, err = native.NotesMerge("native", "theirs")
+	assert.NoError(t, err)
+
+	wanted, _ := cli.NotesList("cli")
+	got, _ := native.NotesList("native")
+	assert.Equal(t, wanted, got)
+	assert.Equal(t, "ours", runGit("notes", "--ref", "native", "show", hashes[0]))
+	assert.Equal(t, "theirs", runGit("notes", "--ref", "native", "show", hashes[2]))
+	assert.Equal(t, ours+" "+runGit("rev-parse", "refs/notes/theirs"), runGit("log", "-1", "--format=%P", "refs/notes/native"))
+
+	_, err = native.DeleteNotesRef("theirs")
+	assert.NoError(t, err)
+	_, err = native.NotesMerge("native", "theirs")
+	assert.Error(t, err)
+}
+
+func TestUpdateNotesRef(t *testing.T) {
+	newTestRepo(t, 2)
+	tip := runGit("rev-parse", "refs/notes/"+testNotesRef)
+	previous := runGit("rev-parse", tip+"^")
+
+	for _, w := range []struct {
+		name    string
+		wrapper interface {
+			UpdateNotesRef(notesRef, hash string) (string, error)
+		}
+	}{
+		{name: "cli", wrapper: &GoGitCmdWrapper{}},
+		{name: "native", wrapper: &GoGitNativeWrapper{}},
+	} {
+		t.Run(w.name, func(t *testing.T) {
+			_, err := w.wrapper.UpdateNotesRef(w.name, previous)
+			assert.NoError(t, err)
+			assert.Equal(t, previous, runGit("rev-parse", "refs/notes/"+w.name))
+
+			_, err = w.wrapper.UpdateNotesRef(w.name, strings.Repeat("0", 39)+"1")
+			assert.Error(t, err)
+		})
+	}
+}
+
+// runGit runs a git command in the working directory, and returns its trimmed output
+func runGit(args ...string) string {
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		panic(string(out))
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func notesOf(t *testing.T, hash string) string {
+	note, err := (&GoGitCmdWrapper{}).NotesShow(testNotesRef, hash)
+	assert.NoError(t, err)
+	return note
+}
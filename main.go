@@ -6,13 +6,20 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/philips-software/gino-keva/internal/git"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
 )
 
 const (
 	envPrefix = "GINO_KEVA"
 )
 
+// Exit codes
+const (
+	exitCodeError              = 1
+	exitCodeKeyNotFound        = 2
+	exitCodePreconditionFailed = 3
+)
+
 func checkIfError(err error) {
 	if err != nil {
 		log.Fatal(err)
@@ -30,21 +37,37 @@ func main() {
 		root.SilenceUsage = true
 		root.SilenceErrors = true
 
-		ctx := ContextWithGitWrapper(context.Background(), &git.GoGitCmdWrapper{})
+		backend := newGitBackend()
+		ctx := ContextWithGitWrapper(context.Background(), backend)
 
 		err = root.ExecuteContext(ctx)
 		attemptsLeft--
-		uc, upstreamChanged := err.(*UpstreamChanged)
+		_, upstreamChanged := err.(*ginokeva.UpstreamChanged)
 
-		if attemptsLeft > 0 && upstreamChanged && uc.fetchEnabled {
+		if attemptsLeft > 0 && upstreamChanged && globalFlags.Fetch {
 			log.WithField("attemptsLeft", attemptsLeft).Info("Upstream has changed in the meanwhile. Starting again from fetch")
+			err = backend.rollbackNotes(newStore(backend))
+			if err != nil {
+				break
+			}
 		} else {
 			break
 		}
 	}
 
 	if err != nil {
-		log.Fatal(err)
-		os.Exit(1)
+		log.Error(err)
+		os.Exit(getExitCode(err))
+	}
+}
+
+func getExitCode(err error) int {
+	switch err.(type) {
+	case *ginokeva.KeyNotFound:
+		return exitCodeKeyNotFound
+	case *ginokeva.PreconditionFailed:
+		return exitCodePreconditionFailed
+	default:
+		return exitCodeError
 	}
 }
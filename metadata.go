@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// hostname returns the name of the host. Overridden in tests
+var hostname = os.Hostname
+
+// ciJobURLs lists how to construct the URL of the current CI job for the supported CI systems, based on their
+// environment variables. The first CI system for which all variables are set is used
+var ciJobURLs = []struct {
+	format string
+	envs   []string
+}{
+	// GitHub Actions
+	{format: "%s/%s/actions/runs/%s", envs: []string{"GITHUB_SERVER_URL", "GITHUB_REPOSITORY", "GITHUB_RUN_ID"}},
+	// GitLab CI
+	{format: "%s", envs: []string{"CI_JOB_URL"}},
+	// Azure Pipelines
+	{format: "%s/%s/_build/results?buildId=%s", envs: []string{"SYSTEM_COLLECTIONURI", "SYSTEM_TEAMPROJECT", "BUILD_BUILDID"}},
+	// Jenkins
+	{format: "%s", envs: []string{"BUILD_URL"}},
+}
+
+// collectMetadata returns the metadata to record on new events. Overridden in tests, since it depends on the environment
+var collectMetadata = getMetadata
+
+// getMetadata records the name of the git user only, unless --record-identity asks for the email address and hostname
+// as well, since these identify people and machines beyond what the commits in the repository already do
+func getMetadata(gitWrapper GitWrapper, message string) *event.Metadata {
+	timestamp := now().UTC().Truncate(time.Second)
+	metadata := &event.Metadata{
+		Timestamp: &timestamp,
+		User:      getGitUser(gitWrapper, globalFlags.RecordIdentity),
+		CIJobURL:  getCIJobURL(),
+		Message:   message,
+	}
+
+	if !globalFlags.RecordIdentity {
+		return metadata
+	}
+
+	if h, err := hostname(); err == nil {
+		metadata.Hostname = h
+	} else {
+		log.WithField("error", err).Debug("Unable to determine hostname")
+	}
+
+	return metadata
+}
+
+func getGitUser(gitWrapper GitWrapper, withEmail bool) string {
+	getConfig := func(key string) string {
+		out, err := gitWrapper.ConfigGet(key)
+		if err != nil {
+			log.WithField("key", key).Debug("Git config not set")
+			return ""
+		}
+		return strings.TrimSpace(out)
+	}
+
+	name := getConfig("user.name")
+	if !withEmail {
+		return name
+	}
+
+	email := getConfig("user.email")
+	switch {
+	case email == "":
+		return name
+	case name == "":
+		return fmt.Sprintf("<%s>", email)
+	default:
+		return fmt.Sprintf("%s <%s>", name, email)
+	}
+}
+
+func getCIJobURL() string {
+	for _, ci := range ciJobURLs {
+		values := []interface{}{}
+		for _, env := range ci.envs {
+			if v := os.Getenv(env); v != "" {
+				values = append(values, strings.TrimSuffix(v, "/"))
+			}
+		}
+
+		if len(values) == len(ci.envs) {
+			return fmt.Sprintf(ci.format, values...)
+		}
+	}
+
+	return ""
+}
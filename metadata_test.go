@@ -0,0 +1,188 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+var ciEnvs = []string{
+	"GITHUB_SERVER_URL", "GITHUB_REPOSITORY", "GITHUB_RUN_ID",
+	"CI_JOB_URL",
+	"SYSTEM_COLLECTIONURI", "SYSTEM_TEAMPROJECT", "BUILD_BUILDID",
+	"BUILD_URL",
+}
+
+func clearCIEnvs(t *testing.T) {
+	for _, env := range ciEnvs {
+		t.Setenv(env, "")
+	}
+}
+
+func TestGetCIJobURL(t *testing.T) {
+	testCases := []struct {
+		name   string
+		envs   map[string]string
+		wanted string
+	}{
+		{
+			name:   "No CI",
+			envs:   map[string]string{},
+			wanted: "",
+		},
+		{
+			name: "GitHub Actions",
+			envs: map[string]string{
+				"GITHUB_SERVER_URL": "https://github.com",
+				"GITHUB_REPOSITORY": "philips-software/gino-keva",
+				"GITHUB_RUN_ID":     "42",
+			},
+			wanted: "https://github.com/philips-software/gino-keva/actions/runs/42",
+		},
+		{
+			name: "GitHub Actions incomplete",
+			envs: map[string]string{
+				"GITHUB_SERVER_URL": "https://github.com",
+			},
+			wanted: "",
+		},
+		{
+			name: "GitLab CI",
+			envs: map[string]string{
+				"CI_JOB_URL": "https://gitlab.com/foo/bar/-/jobs/42",
+			},
+			wanted: "https://gitlab.com/foo/bar/-/jobs/42",
+		},
+		{
+			name: "Azure Pipelines",
+			envs: map[string]string{
+				"SYSTEM_COLLECTIONURI": "https://dev.azure.com/foo/",
+				"SYSTEM_TEAMPROJECT":   "bar",
+				"BUILD_BUILDID":        "42",
+			},
+			wanted: "https://dev.azure.com/foo/bar/_build/results?buildId=42",
+		},
+		{
+			name: "Jenkins",
+			envs: map[string]string{
+				"BUILD_URL": "https://jenkins.example.com/job/foo/42/",
+			},
+			wanted: "https://jenkins.example.com/job/foo/42",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearCIEnvs(t)
+			for k, v := range tc.envs {
+				t.Setenv(k, v)
+			}
+
+			assert.Equal(t, tc.wanted, getCIJobURL())
+		})
+	}
+}
+
+func TestSetWithMetadata(t *testing.T) {
+	defer func(f func(GitWrapper, string) *event.Metadata) { collectMetadata = f }(collectMetadata)
+	defer func(f func() time.Time) { now = f }(now)
+	defer func(f func() (string, error)) { hostname = f }(hostname)
+
+	collectMetadata = getMetadata
+	now = func() time.Time { return time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC) }
+	hostname = func() (string, error) { return "build-agent", nil }
+	clearCIEnvs(t)
+	t.Setenv("CI_JOB_URL", "https://gitlab.com/foo/bar/-/jobs/42")
+
+	timestamp := now()
+	wantedMetadata := &event.Metadata{
+		Timestamp: &timestamp,
+		User:      "Jane Doe",
+		CIJobURL:  "https://gitlab.com/foo/bar/-/jobs/42",
+		Message:   "Release 1.1.0",
+	}
+	wantedMetadataWithIdentity := &event.Metadata{
+		Timestamp: &timestamp,
+		User:      "Jane Doe <jane@example.com>",
+		Hostname:  "build-agent",
+		CIJobURL:  "https://gitlab.com/foo/bar/-/jobs/42",
+		Message:   "Release 1.1.0",
+	}
+
+	testCases := []struct {
+		name           string
+		args           []string
+		wantedEvent    event.Event
+		wantedMetadata *event.Metadata
+	}{
+		{
+			name:           "set",
+			args:           []string{"set", "key", "value", "-m", "Release 1.1.0"},
+			wantedEvent:    event.TestDataSetKeyValue,
+			wantedMetadata: wantedMetadata,
+		},
+		{
+			name:           "unset",
+			args:           []string{"unset", "key", "--message", "Release 1.1.0"},
+			wantedEvent:    event.TestDataUnsetKey,
+			wantedMetadata: wantedMetadata,
+		},
+		{
+			name:           "set recording identity",
+			args:           []string{"set", "key", "value", "-m", "Release 1.1.0", "--record-identity"},
+			wantedEvent:    event.TestDataSetKeyValue,
+			wantedMetadata: wantedMetadataWithIdentity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wantedEvent := tc.wantedEvent
+			wantedEvent.Metadata = tc.wantedMetadata
+
+			forEachBackend(t, func(t *testing.T, backend string) {
+				repo := newTestRepo(t, backend)
+				repo.git("config", "user.name", "Jane Doe")
+				repo.git("config", "user.email", "jane@example.com")
+
+				args := disableFetch(tc.args)
+				_, err := repo.run(args...)
+
+				assert.NoError(t, err)
+				assert.Equal(t, eventsJSON(t, []event.Event{wantedEvent}), repo.note("HEAD"))
+			})
+		})
+	}
+}
+
+func TestDescribeMetadata(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata *event.Metadata
+		wanted   string
+	}{
+		{
+			name:     "No metadata",
+			metadata: nil,
+			wanted:   "",
+		},
+		{
+			name:     "User only",
+			metadata: &event.Metadata{User: "Jane Doe"},
+			wanted:   " by Jane Doe",
+		},
+		{
+			name:     "User and message",
+			metadata: &event.Metadata{User: "Jane Doe", Message: "Release 1.1.0"},
+			wanted:   " by Jane Doe: Release 1.1.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wanted, describeMetadata(tc.metadata))
+		})
+	}
+}
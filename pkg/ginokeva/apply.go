@@ -0,0 +1,43 @@
+package ginokeva
+
+import (
+	"github.com/philips-software/gino-keva/internal/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// Operation represents a single set or unset operation
+type Operation struct {
+	Unset bool
+	Key   string
+	Value string // Ignored when unsetting
+}
+
+// Apply applies the operations in order, in a single note update
+func (s *Store) Apply(operations []Operation) error {
+	events := []event.Event{}
+	for _, o := range operations {
+		var e *event.Event
+		var err error
+		if o.Unset {
+			e, err = event.NewUnsetEvent(o.Key)
+		} else {
+			e, err = event.NewSetEvent(o.Key, o.Value)
+		}
+		if err != nil {
+			return err
+		}
+		events = append(events, *e)
+	}
+
+	commitHash, err := s.addEvents(s.rev, events)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"hash":   commitHash,
+		"events": len(events),
+	}).Debug("Events applied successfully")
+
+	return nil
+}
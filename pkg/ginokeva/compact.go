@@ -0,0 +1,109 @@
+package ginokeva
+
+import (
+	"sort"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// Compact adds a checkpoint event holding all key/values to the note of the commit. Replaying events skips the notes
+// of the ancestors of the most recent checkpoint, so these no longer need to be read. Returns the commit hash and number of keys.
+// Fails if the value of any key cannot be calculated, since the checkpoint would lose the events of that key
+func (s *Store) Compact() (commitHash string, numberOfKeys int, err error) {
+	// Expired values are included, since expiry is evaluated when reading the checkpoint
+	values, err := s.calculateKeyValuesWithExpired(s.rev, true)
+	if err != nil {
+		return "", 0, err
+	}
+	if err = values.firstErr(); err != nil {
+		return "", 0, err
+	}
+
+	return s.compact(s.rev, values)
+}
+
+func (s *Store) compact(rev string, values *Values) (commitHash string, numberOfKeys int, err error) {
+	checkpoint := newCheckpointEvent(values)
+	commitHash, err = s.persistNewEvents(rev, []event.Event{*checkpoint})
+	if err != nil {
+		return "", 0, err
+	}
+
+	log.WithFields(log.Fields{
+		"hash":  commitHash,
+		"keys":  values.Count(),
+		"unset": len(values.unset),
+	}).Debug("Checkpoint event added successfully")
+
+	return commitHash, values.Count(), nil
+}
+
+// newCheckpointEvent returns a checkpoint holding all key/values, along with the keys which were unset
+func newCheckpointEvent(values *Values) *event.Event {
+	snapshot := []event.SnapshotEntry{}
+	for _, k := range values.order { // In order of last modification
+		metadata := values.metadata[k]
+		snapshot = append(snapshot, event.SnapshotEntry{
+			Key:       k,
+			Value:     string(values.values[k]),
+			ValueType: metadata.valueType,
+			ExpiresAt: metadata.expiresAt,
+			Commit:    metadata.commit,
+			Metadata:  metadata.origin,
+		})
+	}
+
+	unset := []string{}
+	for k := range values.unset {
+		unset = append(unset, k)
+	}
+	sort.Strings(unset)
+
+	for _, k := range unset {
+		metadata := values.unset[k]
+		snapshot = append(snapshot, event.SnapshotEntry{
+			Key:      k,
+			Unset:    true,
+			Commit:   metadata.commit,
+			Metadata: metadata.origin,
+		})
+	}
+
+	return event.NewCheckpointEvent(snapshot)
+}
+
+// checkpointIfDue writes a checkpoint if at least interval notes were written since the most recent checkpoint
+func (s *Store) checkpointIfDue(rev string, interval int) error {
+	notesSinceCheckpoint := 0
+	err := s.walkNotes(rev, func(n string, events []event.Event) (walkAction, error) {
+		if containsCheckpoint(events) {
+			return walkStop, nil
+		}
+		notesSinceCheckpoint++
+		if notesSinceCheckpoint < interval {
+			return walkContinue, nil
+		}
+		return walkStop, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if notesSinceCheckpoint < interval {
+		return nil
+	}
+
+	values, err := s.calculateKeyValuesWithExpired(rev, true)
+	if err != nil {
+		return err
+	}
+	if err = values.firstErr(); err != nil {
+		log.WithError(err).Warning("Not writing checkpoint, since not every value can be calculated")
+		return nil
+	}
+
+	log.WithField("notesSinceCheckpoint", notesSinceCheckpoint).Debug("Writing checkpoint...")
+	_, _, err = s.compact(rev, values)
+	return err
+}
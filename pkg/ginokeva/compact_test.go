@@ -0,0 +1,157 @@
+package ginokeva
+
+import (
+	"testing"
+	"time"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/gitfake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointReplayIsIdentical(t *testing.T) {
+	currentTime := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	past := time.Date(2022, 7, 1, 11, 0, 0, 0, time.UTC)
+	expiredFooBar := event.TestDataSetFooBar
+	expiredFooBar.ExpiresAt = &past
+
+	history := []event.Event{
+		event.TestDataIncrCounter5,
+		expiredFooBar,
+		event.TestDataAppendRegionsEU,
+		event.TestDataSetKeyOtherValue,
+		event.TestDataSetCounterInt12,
+		event.TestDataSetKeyValue,
+	}
+	for i := range history {
+		history[i].Commit = string(rune('a' + i))
+	}
+
+	testCases := []struct {
+		name        string
+		newerEvents []event.Event
+	}{
+		{
+			name:        "No newer events",
+			newerEvents: []event.Event{},
+		},
+		{
+			name:        "Newer events on top of checkpoint",
+			newerEvents: []event.Event{event.TestDataDecrCounter5, event.TestDataAppendRegionsUS, event.TestDataUnsetKey},
+		},
+		{
+			name:        "Newer set of expired key",
+			newerEvents: []event.Event{event.TestDataSetFooBar},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			snapshot, err := calculateKeyValuesFromEvents(history, currentTime, true)
+			assert.NoError(t, err)
+			checkpoint := newCheckpointEvent(snapshot)
+
+			// Events older than the checkpoint must not affect the outcome
+			obsolete := event.TestDataSetKeyOtherValue
+
+			withCheckpoint := append(append([]event.Event{}, tc.newerEvents...), *checkpoint, obsolete)
+			withoutCheckpoint := append(append([]event.Event{}, tc.newerEvents...), history...)
+
+			for _, includeExpired := range []bool{false, true} {
+				got, err := calculateKeyValuesFromEvents(withCheckpoint, currentTime, includeExpired)
+				assert.NoError(t, err)
+				wanted, err := calculateKeyValuesFromEvents(withoutCheckpoint, currentTime, includeExpired)
+				assert.NoError(t, err)
+
+				assert.Equal(t, wanted, got)
+			}
+		})
+	}
+}
+
+func TestReplaySkipsAncestorsOfCheckpoint(t *testing.T) {
+	repo := &spyRepository{Repository: gitfake.NewRepository()}
+	store := NewStore(repo, Options{})
+
+	repo.Commit("Old")
+	assert.NoError(t, store.Set("old", "1"))
+	checkpoint := repo.Commit("Checkpoint")
+	_, _, err := store.Compact()
+	assert.NoError(t, err)
+	newer := repo.Commit("New")
+	assert.NoError(t, store.Set("new", "1"))
+
+	repo.shown = nil
+	values, err := store.List()
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Value{"old": "1", "new": "1"}, values.Iterate())
+	assert.Equal(t, []string{newer, checkpoint}, repo.shown)
+}
+
+func TestReplayOfMergedBranchAfterCheckpoint(t *testing.T) {
+	repo := gitfake.NewRepository()
+	store := NewStore(repo, Options{})
+
+	repo.Commit("Base")
+	assert.NoError(t, store.Set("base", "1"))
+	assert.NoError(t, repo.Branch("feature"))
+	assert.NoError(t, repo.Checkout("feature"))
+	repo.Commit("Feature")
+	assert.NoError(t, store.Set("Y", "1"))
+	assert.NoError(t, repo.Checkout(gitfake.DefaultBranch))
+	repo.Commit("Main")
+	assert.NoError(t, store.Set("X", "1"))
+	_, err := repo.Merge("feature", "Merge feature")
+	assert.NoError(t, err)
+
+	wanted, err := store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Value{"base": "1", "X": "1", "Y": "1"}, wanted.Iterate())
+
+	// The checkpoint on main doesn't hold the key/values of the feature branch
+	_, _, err = store.At("HEAD~1").Compact()
+	assert.NoError(t, err)
+
+	got, err := store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, wanted.Iterate(), got.Iterate())
+
+	// Neither does a checkpoint on the feature branch hold those of main
+	_, _, err = store.At("feature").Compact()
+	assert.NoError(t, err)
+
+	got, err = store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, wanted.Iterate(), got.Iterate())
+}
+
+func TestCheckpointKeepsUnsetKeysUnset(t *testing.T) {
+	repo := gitfake.NewRepository()
+	store := NewStore(repo, Options{})
+
+	repo.Commit("Base")
+	assert.NoError(t, store.Set("K", "1"))
+	assert.NoError(t, repo.Branch("feature"))
+	assert.NoError(t, repo.Checkout("feature"))
+	repo.Commit("Feature")
+	assert.NoError(t, store.Set("K", "2"))
+	assert.NoError(t, repo.Checkout(gitfake.DefaultBranch))
+	repo.Commit("Main")
+	assert.NoError(t, store.Unset("K"))
+	_, err := repo.Merge("feature", "Merge feature")
+	assert.NoError(t, err)
+
+	wanted, err := store.List()
+	assert.NoError(t, err)
+	assert.False(t, wanted.HasKey("K"))
+
+	// The set on the feature branch isn't an ancestor of the checkpoint, so it's still replayed after it
+	_, numberOfKeys, err := store.At("HEAD~1").Compact()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, numberOfKeys)
+
+	got, err := store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, wanted.Iterate(), got.Iterate())
+}
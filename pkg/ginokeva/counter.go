@@ -0,0 +1,67 @@
+package ginokeva
+
+import (
+	"strconv"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// Incr increments the integer value of the key by delta, and returns the resulting value. A key which isn't set yet
+// starts at 0
+func (s *Store) Incr(key string, delta int64) (string, error) {
+	return s.count(event.Incr, key, delta)
+}
+
+// Decr decrements the integer value of the key by delta, and returns the resulting value. A key which isn't set yet
+// starts at 0
+func (s *Store) Decr(key string, delta int64) (string, error) {
+	return s.count(event.Decr, key, delta)
+}
+
+// count adds an incr/decr event and returns the resulting value
+func (s *Store) count(eventType event.Type, key string, delta int64) (string, error) {
+	var counterEvent *event.Event
+	var err error
+	if eventType == event.Incr {
+		counterEvent, err = event.NewIncrEvent(key, delta)
+	} else {
+		counterEvent, err = event.NewDecrEvent(key, delta)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	values, err := s.calculateKeyValues(s.rev)
+	if err != nil {
+		return "", err
+	}
+	if err = values.Err(key); err != nil {
+		return "", err
+	}
+
+	var current int64
+	if values.HasKey(key) {
+		current, err = strconv.ParseInt(string(values.Get(key)), 10, 64)
+		if err != nil {
+			return "", &NotAnInteger{Key: key, Value: string(values.Get(key))}
+		}
+	}
+
+	commitHash, err := s.addEvents(s.rev, []event.Event{*counterEvent})
+	if err != nil {
+		return "", err
+	}
+
+	log.WithFields(log.Fields{
+		"hash":  commitHash,
+		"key":   key,
+		"type":  eventType,
+		"delta": delta,
+	}).Debug("Counter event added successfully")
+
+	if eventType == event.Decr {
+		delta = -delta
+	}
+	return strconv.FormatInt(current+delta, 10), nil
+}
@@ -0,0 +1,149 @@
+package ginokeva
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UpstreamChanged error indicates there's been a change in the upstream preventing a fetch/push without force
+type UpstreamChanged struct {
+}
+
+func (UpstreamChanged) Error() string {
+	return "Upstream has changed in the meanwhile"
+}
+
+func checkIfErrorStringIsUpstreamChanged(s string) bool {
+	return strings.Contains(s, "! [rejected]") || strings.Contains(s, "! [remote rejected]")
+}
+
+// FetchFailed error indicates the notes couldn't be fetched from any of the remotes
+type FetchFailed struct {
+	Errors map[string]error // Remote name -> error
+}
+
+func (f FetchFailed) Error() string {
+	return describeRemoteErrors("Failed to fetch notes", f.Errors)
+}
+
+// PushFailed error indicates the notes couldn't be pushed to one or more remotes
+type PushFailed struct {
+	Errors map[string]error // Remote name -> error
+}
+
+func (p PushFailed) Error() string {
+	return describeRemoteErrors("Failed to push notes", p.Errors)
+}
+
+func describeRemoteErrors(msg string, errs map[string]error) string {
+	remotes := []string{}
+	for r := range errs {
+		remotes = append(remotes, r)
+	}
+	sort.Strings(remotes)
+
+	for _, r := range remotes {
+		msg += fmt.Sprintf("\n  %v: %v", r, strings.TrimSpace(errs[r].Error()))
+	}
+	return msg
+}
+
+// NoRemoteRef error indicates that the remote reference isn't there
+type NoRemoteRef struct {
+}
+
+func (NoRemoteRef) Error() string {
+	return "No remote reference found"
+}
+
+func checkIfErrorStringIsNoRemoteRef(s string) bool {
+	return strings.HasPrefix(strings.ToLower(s), "fatal: couldn't find remote ref refs/notes/")
+}
+
+// NoNotePresent error indicates there's no note present on the commit
+type NoNotePresent struct {
+}
+
+func (NoNotePresent) Error() string {
+	return "No note present on commit"
+}
+
+func checkIfErrorStringIsNoNotePresent(s string) bool {
+	return strings.HasPrefix(strings.ToLower(s), "error: no note found for object ")
+}
+
+// KeyNotFound error indicates the requested key isn't present in the snapshot
+type KeyNotFound struct {
+	Key string
+}
+
+func (k KeyNotFound) Error() string {
+	return fmt.Sprintf("Key not found: %v", k.Key)
+}
+
+// PreconditionFailed error indicates the current value of a key doesn't match the expected value
+type PreconditionFailed struct {
+	Key      string
+	Expected *string // nil means the key is expected to be absent
+	Actual   *string // nil means the key is absent
+}
+
+func (p PreconditionFailed) Error() string {
+	describe := func(v *string) string {
+		if v == nil {
+			return "absent"
+		}
+		return fmt.Sprintf("%q", *v)
+	}
+
+	return fmt.Sprintf("Precondition failed for key %v: expected %v, got %v", p.Key, describe(p.Expected), describe(p.Actual))
+}
+
+// NotAnInteger error indicates an incr/decr event was applied to a value which isn't an integer
+type NotAnInteger struct {
+	Key   string
+	Value string
+}
+
+func (n NotAnInteger) Error() string {
+	return fmt.Sprintf("Cannot increment/decrement key %v: value %q is not an integer", n.Key, n.Value)
+}
+
+// UnknownEventType error indicates an event of an unknown type was encountered while replaying events
+type UnknownEventType struct {
+	EventType EventType
+}
+
+func (u UnknownEventType) Error() string {
+	return fmt.Sprintf("Unknown event type: %v", u.EventType)
+}
+
+// NotAList error indicates an append/remove event was applied to a value which isn't a list
+type NotAList struct {
+	Key   string
+	Value string
+}
+
+func (n NotAList) Error() string {
+	return fmt.Sprintf("Cannot append/remove items of key %v: value %q is not a list", n.Key, n.Value)
+}
+
+func convertGitOutputToError(out string, errorCode error) (err error) {
+	if errorCode == nil {
+		return nil
+	}
+
+	if checkIfErrorStringIsNoRemoteRef(out) {
+		err = &NoRemoteRef{}
+	} else if checkIfErrorStringIsNoNotePresent(out) {
+		err = &NoNotePresent{}
+	} else if checkIfErrorStringIsUpstreamChanged(out) {
+		err = &UpstreamChanged{}
+	} else {
+		err = errors.New(out)
+	}
+
+	return err
+}
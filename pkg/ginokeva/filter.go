@@ -0,0 +1,172 @@
+package ginokeva
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// InvalidFilter error indicates a key filter could not be parsed
+type InvalidFilter struct {
+	msg string
+}
+
+func (i InvalidFilter) Error() string {
+	return fmt.Sprintf("Invalid filter: %v", i.msg)
+}
+
+// KeyFilter selects keys from a collection of values
+type KeyFilter struct {
+	prefixes    []string
+	globs       []*regexp.Regexp
+	regexes     []*regexp.Regexp
+	excludes    []*regexp.Regexp
+	stripPrefix bool
+}
+
+// NewKeyFilter returns a new key filter. A key is selected if it matches any of the prefixes (if any), any of the
+// globs (if any) and any of the regular expressions (if any), and none of the exclude globs. Globs use the syntax of
+// path.Match, except that * and ? match the namespace separator as well, so ns* matches ns/foo
+func NewKeyFilter(prefixes, globs, regexes, excludes []string, stripPrefix bool) (*KeyFilter, error) {
+	f := &KeyFilter{
+		prefixes:    prefixes,
+		stripPrefix: stripPrefix,
+	}
+
+	for _, g := range globs {
+		re, err := compileGlob(g)
+		if err != nil {
+			return nil, &InvalidFilter{msg: fmt.Sprintf("glob %q: %v", g, err)}
+		}
+		f.globs = append(f.globs, re)
+	}
+
+	for _, g := range excludes {
+		re, err := compileGlob(g)
+		if err != nil {
+			return nil, &InvalidFilter{msg: fmt.Sprintf("glob %q: %v", g, err)}
+		}
+		f.excludes = append(f.excludes, re)
+	}
+
+	for _, r := range regexes {
+		re, err := regexp.Compile(r)
+		if err != nil {
+			return nil, &InvalidFilter{msg: fmt.Sprintf("regex %q: %v", r, err)}
+		}
+		f.regexes = append(f.regexes, re)
+	}
+
+	if stripPrefix && len(prefixes) == 0 {
+		return nil, &InvalidFilter{msg: "cannot strip prefix without specifying a prefix"}
+	}
+
+	return f, nil
+}
+
+// Matches returns true if the key is selected by the filter
+func (f KeyFilter) Matches(key string) bool {
+	if _, ok := f.matchingPrefix(key); len(f.prefixes) > 0 && !ok {
+		return false
+	}
+
+	if len(f.globs) > 0 && !matchesAny(f.globs, key) {
+		return false
+	}
+
+	if len(f.regexes) > 0 && !matchesAny(f.regexes, key) {
+		return false
+	}
+
+	return !matchesAny(f.excludes, key)
+}
+
+// Apply returns a new collection of values holding only the selected keys, with the prefix stripped if requested
+func (f KeyFilter) Apply(values *Values) (*Values, error) {
+	filtered := values.Filter(f.Matches)
+	if !f.stripPrefix {
+		return filtered, nil
+	}
+
+	stripped := NewValues()
+	stripped.SetSortOrder(filtered.sortOrder)
+	for _, k := range filtered.order {
+		prefix, _ := f.matchingPrefix(k)
+		strippedKey := strings.TrimPrefix(k, prefix)
+		if strippedKey == "" {
+			return nil, &InvalidFilter{msg: fmt.Sprintf("key %q is empty after stripping prefix", k)}
+		}
+		if stripped.HasKey(strippedKey) {
+			return nil, &InvalidFilter{msg: fmt.Sprintf("multiple keys result in %q after stripping prefix", strippedKey)}
+		}
+		stripped.add(strippedKey, filtered.Get(k), filtered.metadata[k])
+	}
+
+	return stripped, nil
+}
+
+func (f KeyFilter) matchingPrefix(key string) (string, bool) {
+	for _, p := range f.prefixes {
+		if strings.HasPrefix(key, p) {
+			return p, true
+		}
+	}
+
+	return "", false
+}
+
+func matchesAny(res []*regexp.Regexp, key string) bool {
+	for _, re := range res {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compileGlob translates a glob into a regular expression matching the whole key. Unlike with path.Match, * and ?
+// match the namespace separator as well
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	if _, err := path.Match(glob, ""); err != nil { // Leave validating the syntax to path.Match
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+
+	// Every literal character is written as \x{...}, so none has a special meaning in the regular expression
+	literal := func(r rune) { fmt.Fprintf(&b, `\x{%x}`, r) }
+
+	inClass := false
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\':
+			i++
+			literal(runes[i])
+		case inClass && (r == ']' || r == '-'):
+			inClass = r != ']'
+			b.WriteRune(r)
+		case inClass:
+			literal(r)
+		case r == '[':
+			inClass = true
+			b.WriteRune(r)
+			if i+1 < len(runes) && runes[i+1] == '^' {
+				i++
+				b.WriteRune('^')
+			}
+		case r == '*':
+			b.WriteString(".*")
+		case r == '?':
+			b.WriteString(".")
+		default:
+			literal(r)
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,162 @@
+package ginokeva
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyFilter(t *testing.T) {
+	keys := []string{"COMPONENT_foo", "COMPONENT_bar", "COMPONENT_baz-test", "OTHER_foo", "counter", "svc/foo_test"}
+
+	testCases := []struct {
+		name        string
+		prefixes    []string
+		globs       []string
+		regexes     []string
+		excludes    []string
+		stripPrefix bool
+		wantKeys    []string
+	}{
+		{
+			name:     "No filter",
+			wantKeys: []string{"COMPONENT_bar", "COMPONENT_baz-test", "COMPONENT_foo", "OTHER_foo", "counter", "svc/foo_test"},
+		},
+		{
+			name:     "Prefix",
+			prefixes: []string{"COMPONENT_"},
+			wantKeys: []string{"COMPONENT_bar", "COMPONENT_baz-test", "COMPONENT_foo"},
+		},
+		{
+			name:     "Multiple prefixes",
+			prefixes: []string{"COMPONENT_", "OTHER_"},
+			wantKeys: []string{"COMPONENT_bar", "COMPONENT_baz-test", "COMPONENT_foo", "OTHER_foo"},
+		},
+		{
+			name:     "Glob",
+			globs:    []string{"*_foo"},
+			wantKeys: []string{"COMPONENT_foo", "OTHER_foo"},
+		},
+		{
+			name:     "Glob matches across namespaces",
+			globs:    []string{"sv*"},
+			wantKeys: []string{"svc/foo_test"},
+		},
+		{
+			name:     "Glob with character class and escaped characters",
+			globs:    []string{"[^A-Z]*\\_test", "COMPONENT_ba[\\-r]"},
+			wantKeys: []string{"COMPONENT_bar", "svc/foo_test"},
+		},
+		{
+			name:     "Exclude matches across namespaces",
+			excludes: []string{"*_test", "[C]*"},
+			wantKeys: []string{"OTHER_foo", "counter"},
+		},
+		{
+			name:     "Regex",
+			regexes:  []string{"^COMPONENT_ba[rz]"},
+			wantKeys: []string{"COMPONENT_bar", "COMPONENT_baz-test"},
+		},
+		{
+			name:     "Prefix and exclude",
+			prefixes: []string{"COMPONENT_"},
+			excludes: []string{"*-test"},
+			wantKeys: []string{"COMPONENT_bar", "COMPONENT_foo"},
+		},
+		{
+			name:     "Prefix and glob",
+			prefixes: []string{"COMPONENT_"},
+			globs:    []string{"*o*"},
+			wantKeys: []string{"COMPONENT_foo"},
+		},
+		{
+			name:        "Strip prefix",
+			prefixes:    []string{"COMPONENT_"},
+			stripPrefix: true,
+			wantKeys:    []string{"bar", "baz-test", "foo"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values := NewValues()
+			for _, k := range keys {
+				values.Add(k, Value(k))
+			}
+
+			filter, err := NewKeyFilter(tc.prefixes, tc.globs, tc.regexes, tc.excludes, tc.stripPrefix)
+			assert.NoError(t, err)
+
+			got, err := filter.Apply(values)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantKeys, got.Keys())
+		})
+	}
+}
+
+func TestInvalidKeyFilter(t *testing.T) {
+	testCases := []struct {
+		name        string
+		globs       []string
+		regexes     []string
+		stripPrefix bool
+	}{
+		{
+			name:  "Invalid glob",
+			globs: []string{"[foo"},
+		},
+		{
+			name:  "Glob ending with escape character",
+			globs: []string{"foo\\"},
+		},
+		{
+			name:    "Invalid regex",
+			regexes: []string{"(foo"},
+		},
+		{
+			name:        "Strip prefix without prefix",
+			stripPrefix: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewKeyFilter(nil, tc.globs, tc.regexes, nil, tc.stripPrefix)
+
+			if assert.Error(t, err) {
+				assert.IsType(t, &InvalidFilter{}, err)
+			}
+		})
+	}
+}
+
+func TestKeyFilterStripPrefixEmptyKey(t *testing.T) {
+	t.Run("Error when stripping the prefix results in an empty key", func(t *testing.T) {
+		values := NewValues()
+		values.Add("A_", "1")
+
+		filter, err := NewKeyFilter([]string{"A_"}, nil, nil, nil, true)
+		assert.NoError(t, err)
+
+		_, err = filter.Apply(values)
+		if assert.Error(t, err) {
+			assert.IsType(t, &InvalidFilter{}, err)
+		}
+	})
+}
+
+func TestKeyFilterStripPrefixCollision(t *testing.T) {
+	t.Run("Error when stripping prefixes results in duplicate keys", func(t *testing.T) {
+		values := NewValues()
+		values.Add("A_foo", "1")
+		values.Add("B_foo", "2")
+
+		filter, err := NewKeyFilter([]string{"A_", "B_"}, nil, nil, nil, true)
+		assert.NoError(t, err)
+
+		_, err = filter.Apply(values)
+		if assert.Error(t, err) {
+			assert.IsType(t, &InvalidFilter{}, err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package ginokeva
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// KeyLookup represents the result of looking up a single key
+type KeyLookup struct {
+	Key       string     `json:"key"`
+	Exists    bool       `json:"exists"`
+	Value     *string    `json:"value,omitempty"`
+	Commit    string     `json:"commit,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Metadata  *Metadata  `json:"metadata,omitempty"`
+}
+
+// Get returns the value of the key. Returns KeyNotFound if the key isn't set
+func (s *Store) Get(key string) (string, error) {
+	lookup, err := s.Lookup(key)
+	if err != nil {
+		return "", err
+	}
+
+	if !lookup.Exists {
+		return "", &KeyNotFound{Key: key}
+	}
+
+	return *lookup.Value, nil
+}
+
+// Lookup returns the value of the key along with where and when it was last modified. A key which isn't set isn't
+// an error, but results in a lookup which doesn't exist
+func (s *Store) Lookup(key string) (*KeyLookup, error) {
+	values, err := s.calculateKeyValuesOf(s.rev, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	if err = values.Err(key); err != nil {
+		return nil, err
+	}
+
+	lookup := &KeyLookup{
+		Key:    key,
+		Exists: values.HasKey(key),
+	}
+
+	if lookup.Exists {
+		value := string(values.Get(key))
+		lookup.Value = &value
+		lookup.Commit = values.Commit(key)
+		lookup.ExpiresAt = values.ExpiresAt(key)
+		lookup.Metadata = values.Origin(key)
+	}
+
+	return lookup, nil
+}
+
+// List returns all key/values, excluding expired values. Keys whose value cannot be calculated are left out; use
+// Values.Err to find out why
+func (s *Store) List() (*Values, error) {
+	return s.list(false)
+}
+
+// ListIncludingExpired returns all key/values, including expired values. Keys whose value cannot be calculated are
+// left out; use Values.Err to find out why
+func (s *Store) ListIncludingExpired() (*Values, error) {
+	return s.list(true)
+}
+
+func (s *Store) list(includeExpired bool) (*Values, error) {
+	values, err := s.calculateKeyValuesWithExpired(s.rev, includeExpired)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, err := range values.errors {
+		log.WithField("key", k).WithError(err).Warning("Leaving out key whose value cannot be calculated")
+	}
+	return values, nil
+}
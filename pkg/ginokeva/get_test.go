@@ -0,0 +1,66 @@
+package ginokeva
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	testCases := []struct {
+		name      string
+		key       string
+		depth     uint
+		start     []event.Event
+		wantValue string
+		wantError error
+	}{
+		{
+			name:      "Get value of an existing key",
+			key:       "key",
+			depth:     0,
+			start:     []event.Event{event.TestDataSetKeyValue},
+			wantValue: "value",
+		},
+		{
+			name:      "Get value of a non-existing key",
+			key:       "nonExistingKey",
+			depth:     0,
+			start:     []event.Event{event.TestDataSetKeyValue},
+			wantValue: "",
+			wantError: &KeyNotFound{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newTestRepository(t, tc.start)
+
+			gotValue, err := NewStore(repo, Options{}).Get(tc.key)
+
+			if tc.wantError != nil {
+				if assert.Error(t, err) {
+					assert.IsType(t, tc.wantError, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantValue, gotValue)
+		})
+	}
+}
+
+func TestGetStopsWhenKeyResolved(t *testing.T) {
+	notes := [][]event.Event{{event.TestDataSetKeyValue}}
+	for i := 1; i < 1000; i++ {
+		notes = append(notes, []event.Event{event.TestDataSetFooBar})
+	}
+	repo := newTestRepository(t, notes...)
+
+	gotValue, err := NewStore(repo, Options{}).Get("key")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "value", gotValue)
+	assert.Len(t, repo.shown, 1, "Only the note setting the key should be read")
+}
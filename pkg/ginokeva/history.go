@@ -0,0 +1,53 @@
+package ginokeva
+
+import (
+	"github.com/philips-software/gino-keva/internal/event"
+)
+
+// EventType represents the type of a change to a key
+type EventType = event.Type
+
+// HistoryEntry represents a single change to a key
+type HistoryEntry struct {
+	CommitInfo
+	EventType EventType `json:"type"`
+	Value     *string   `json:"value,omitempty"`
+	Delta     *int64    `json:"delta,omitempty"`
+	Metadata  *Metadata `json:"metadata,omitempty"`
+}
+
+// History returns every change to the key, newest first. It intentionally reads the whole history, since checkpoints
+// don't record when values were changed
+func (s *Store) History(key string) (history []HistoryEntry, err error) {
+	history = []HistoryEntry{}
+
+	err = s.walkNotes(s.rev, func(n string, events []event.Event) (walkAction, error) {
+		var commitInfo *CommitInfo
+		for _, e := range events { // Iterate from new to old (newest event in front)
+			if e.Key != key {
+				continue
+			}
+
+			if commitInfo == nil {
+				commitInfo, err = s.getCommitInfo(n)
+				if err != nil {
+					return walkStop, err
+				}
+			}
+
+			history = append(history, HistoryEntry{
+				CommitInfo: *commitInfo,
+				EventType:  e.EventType,
+				Value:      e.Value,
+				Delta:      e.Delta,
+				Metadata:   e.Metadata,
+			})
+		}
+		return walkContinue, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
@@ -0,0 +1,126 @@
+package ginokeva
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// InvalidKeys error indicates one or more keys in the input are invalid
+type InvalidKeys struct {
+	Errors map[string]error
+}
+
+func (i InvalidKeys) Error() string {
+	keys := []string{}
+	for k := range i.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	msg := fmt.Sprintf("%d invalid key(s) found:", len(keys))
+	for _, k := range keys {
+		msg += fmt.Sprintf("\n  %q: %v", k, i.Errors[k])
+	}
+
+	return msg
+}
+
+// Import sets all key/values in a single note update. With onlyChanged, keys which already have the same value and
+// value type are skipped. With sync, keys which are absent from keyValues are unset. Returns InvalidKeys if any key
+// (or value) is invalid
+func (s *Store) Import(keyValues []KeyValue, onlyChanged bool, sync bool) error {
+	events, err := s.getImportEvents(keyValues, onlyChanged, sync)
+	if err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		log.Info("Nothing to import")
+		return nil
+	}
+
+	commitHash, err := s.addEvents(s.rev, events)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"hash":   commitHash,
+		"events": len(events),
+	}).Debug("Key/values imported successfully")
+
+	return nil
+}
+
+func (s *Store) getImportEvents(keyValues []KeyValue, onlyChanged bool, sync bool) (events []event.Event, err error) {
+	events = []event.Event{}
+	invalidKeys := map[string]error{}
+	imported := map[string]bool{}
+
+	sorted := append([]KeyValue{}, keyValues...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	for _, kv := range sorted {
+		if imported[kv.Key] {
+			invalidKeys[kv.Key] = fmt.Errorf("key occurs more than once")
+			continue
+		}
+		imported[kv.Key] = true
+
+		e, err := event.NewTypedSetEvent(kv.Key, kv.Value, kv.ValueType)
+		if err != nil {
+			invalidKeys[kv.Key] = err
+			continue
+		}
+		events = append(events, *e)
+	}
+
+	if len(invalidKeys) > 0 {
+		return nil, &InvalidKeys{Errors: invalidKeys}
+	}
+
+	if !onlyChanged && !sync {
+		return events, nil
+	}
+
+	current, err := s.calculateKeyValues(s.rev)
+	if err != nil {
+		return nil, err
+	}
+
+	if onlyChanged {
+		changed := []event.Event{}
+		for _, e := range events {
+			if !current.HasKey(e.Key) || current.Get(e.Key) != Value(*e.Value) || current.ValueType(e.Key) != e.ValueType {
+				changed = append(changed, e)
+			}
+		}
+		events = changed
+	}
+
+	if sync {
+		// Keys whose value cannot be calculated are still set, so these are unset as well
+		keys := current.Keys()
+		for k := range current.errors {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if imported[k] {
+				continue
+			}
+
+			e, err := event.NewUnsetEvent(k)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, *e)
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,27 @@
+package ginokeva
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportInvalidKeys(t *testing.T) {
+	t.Run("All invalid keys are reported", func(t *testing.T) {
+		_, err := NewStore(newTestRepository(t), Options{}).getImportEvents([]KeyValue{
+			{Key: "valid", Value: "value"},
+			{Key: "2foo", Value: "value"},
+			{Key: "bar!", Value: "value"},
+			{Key: "also-ok", Value: "value"},
+			{Key: "number", Value: "one", ValueType: Int},
+		}, false, false)
+
+		if assert.Error(t, err) {
+			assert.IsType(t, &InvalidKeys{}, err)
+			assert.Len(t, err.(*InvalidKeys).Errors, 3)
+			assert.Contains(t, err.Error(), `"2foo"`)
+			assert.Contains(t, err.Error(), `"bar!"`)
+			assert.Contains(t, err.Error(), `"number"`)
+		}
+	})
+}
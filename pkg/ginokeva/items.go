@@ -0,0 +1,65 @@
+package ginokeva
+
+import (
+	"github.com/philips-software/gino-keva/internal/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// Append appends the items to the list value of the key. Items are tracked individually, so changes to different
+// items made on different branches are all retained. A key which isn't set yet starts as an empty list
+func (s *Store) Append(key string, items ...string) error {
+	return s.modifyList(event.Append, key, items)
+}
+
+// Remove removes the items from the list value of the key
+func (s *Store) Remove(key string, items ...string) error {
+	return s.modifyList(event.Remove, key, items)
+}
+
+// modifyList adds an append/remove event for each of the items
+func (s *Store) modifyList(eventType event.Type, key string, items []string) error {
+	listEvents := []event.Event{}
+	for _, item := range items {
+		var listEvent *event.Event
+		var err error
+		if eventType == event.Append {
+			listEvent, err = event.NewAppendEvent(key, item)
+		} else {
+			listEvent, err = event.NewRemoveEvent(key, item)
+		}
+		if err != nil {
+			return err
+		}
+		listEvents = append(listEvents, *listEvent)
+	}
+
+	// Refuse to modify a value which isn't a list
+	values, err := s.calculateKeyValues(s.rev)
+	if err != nil {
+		return err
+	}
+	if err = values.Err(key); err != nil {
+		return err
+	}
+	if values.HasKey(key) {
+		if _, err := event.ParseList(string(values.Get(key))); err != nil {
+			return &NotAList{Key: key, Value: string(values.Get(key))}
+		}
+	}
+
+	commitHash, err := s.addEvents(s.rev, listEvents)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		log.WithFields(log.Fields{
+			"hash": commitHash,
+			"key":  key,
+			"type": eventType,
+			"item": item,
+		}).Debug("List event added successfully")
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package ginokeva
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// noteEvents holds the events of the local and the remote note of a commit. Events are nil if there's no such note
+type noteEvents struct {
+	local  []event.Event
+	remote []event.Event
+}
+
+// mergeFrom merges the notes of the remote into the diverged local notes. The remote notes are fetched into a
+// temporary notes ref, after which the union of the events of both notes is written for each commit. Finally the
+// notes refs are merged by git, so the local notes ref descends from the remote one and can be pushed. Returns
+// PreconditionFailed if the precondition of a conditional set which wasn't pushed yet doesn't hold for the remote notes
+func (s *Store) mergeFrom(remote string) error {
+	fetchedRef := fmt.Sprintf("%v-fetched/%v", s.options.NotesRef, remote)
+
+	log.WithFields(log.Fields{
+		"remote":     remote,
+		"fetchedRef": fetchedRef,
+	}).Debug("Fetching notes to merge...")
+
+	out, err := s.git.FetchNotesInto(remote, s.options.NotesRef, fetchedRef)
+	if err != nil {
+		return convertGitOutputToError(out, err)
+	}
+	defer s.git.DeleteNotesRef(fetchedRef)
+
+	err = s.checkPreconditions(fetchedRef)
+	if err != nil {
+		return err
+	}
+
+	notes, err := s.readNotesToMerge(fetchedRef)
+	if err != nil {
+		return err
+	}
+
+	merged, err := s.mergeNotes(notes)
+	if err != nil {
+		return err
+	}
+
+	out, err = s.git.NotesMerge(s.options.NotesRef, fetchedRef)
+	if err != nil {
+		return convertGitOutputToError(out, err)
+	}
+
+	log.WithFields(log.Fields{
+		"remote": remote,
+		"notes":  merged,
+	}).Info("Merged diverged notes")
+
+	return nil
+}
+
+// readNotesToMerge returns the events of the local and remote note of each commit which has either
+func (s *Store) readNotesToMerge(fetchedRef string) (map[string]*noteEvents, error) {
+	notes := map[string]*noteEvents{}
+
+	read := func(notesRef string, set func(n *noteEvents, events []event.Event)) error {
+		blobs, err := getNotesBlobs(s.git, notesRef)
+		if err != nil {
+			return err
+		}
+
+		return s.git.ReadBlobs(func(readBlob func(hash string) (string, error)) error {
+			for hash, blob := range blobs {
+				note, err := readBlob(blob)
+				if err != nil {
+					return err
+				}
+
+				events, _, err := event.Decode(note)
+				if err != nil {
+					return fmt.Errorf("cannot decode note on %v: %w", hash, err)
+				}
+
+				if notes[hash] == nil {
+					notes[hash] = &noteEvents{}
+				}
+				set(notes[hash], events)
+			}
+			return nil
+		})
+	}
+
+	err := read(s.options.NotesRef, func(n *noteEvents, events []event.Event) { n.local = events })
+	if err != nil {
+		return nil, err
+	}
+	err = read(fetchedRef, func(n *noteEvents, events []event.Event) { n.remote = events })
+	if err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+// mergeNotes writes the union of the events of the local and remote note of each commit into the local note, and
+// returns the number of notes written. Events both notes have in common stay in place. Events only the remote note
+// has are ordered before (so older than) events only the local note has, as if the local changes were made after
+// fetching. If both sides have new events, their checkpoints are dropped since they don't account for the events of
+// the other side
+func (s *Store) mergeNotes(notes map[string]*noteEvents) (written int, err error) {
+	hashes := make([]string, 0, len(notes))
+	for h := range notes {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes) // Write notes in a deterministic order
+
+	type split struct {
+		localNew, remoteNew, common []event.Event
+	}
+	splits := map[string]split{}
+	localChanged, remoteChanged := false, false
+
+	for _, h := range hashes {
+		localNew, remoteNew, common, err := splitEvents(notes[h].local, notes[h].remote)
+		if err != nil {
+			return 0, err
+		}
+		splits[h] = split{localNew, remoteNew, common}
+		localChanged = localChanged || len(localNew) > 0
+		remoteChanged = remoteChanged || len(remoteNew) > 0
+	}
+
+	if !remoteChanged {
+		return 0, nil // Local notes hold all events already
+	}
+
+	for _, h := range hashes {
+		sp := splits[h]
+		if len(sp.remoteNew) == 0 && !(localChanged && containsCheckpoint(sp.localNew)) {
+			continue // Git keeps the local note
+		}
+
+		if localChanged {
+			sp.localNew = withoutCheckpoints(sp.localNew)
+			sp.remoteNew = withoutCheckpoints(sp.remoteNew)
+		}
+
+		merged := append(append(append([]event.Event{}, sp.localNew...), sp.remoteNew...), sp.common...)
+		log.WithFields(log.Fields{
+			"hash":   h,
+			"local":  len(sp.localNew),
+			"remote": len(sp.remoteNew),
+		}).Debug("Merging events of note...")
+
+		err = s.persistEvents(h, &merged)
+		if err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	return written, nil
+}
+
+// splitEvents returns the events only the local note has, the events only the remote note has, and the events both
+// have in common at the end (the oldest events). Events are newest first
+func splitEvents(local, remote []event.Event) (localNew, remoteNew, common []event.Event, err error) {
+	localKeys, err := eventKeys(local)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	remoteKeys, err := eventKeys(remote)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	n := 0
+	for n < len(local) && n < len(remote) && localKeys[len(local)-1-n] == remoteKeys[len(remote)-1-n] {
+		n++
+	}
+	localNew, common = local[:len(local)-n], local[len(local)-n:]
+
+	inLocal := map[string]bool{}
+	for _, k := range localKeys[:len(localNew)] {
+		inLocal[k] = true
+	}
+	for i, e := range remote[:len(remote)-n] {
+		if !inLocal[remoteKeys[i]] {
+			remoteNew = append(remoteNew, e)
+		}
+	}
+
+	return localNew, remoteNew, common, nil
+}
+
+// eventKeys returns a key identifying each event, so equal events of different notes can be recognized
+func eventKeys(events []event.Event) ([]string, error) {
+	keys := make([]string, len(events))
+	for i := range events {
+		b, err := json.Marshal(events[i])
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = string(b)
+	}
+	return keys, nil
+}
+
+func withoutCheckpoints(events []event.Event) []event.Event {
+	result := []event.Event{}
+	for _, e := range events {
+		if e.EventType != event.Checkpoint {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// NotesTip returns the hash of the notes commit the notes ref points to, or an empty string if there are no notes
+// yet. Pass it to ResetNotes to roll back the changes made afterwards
+func (s *Store) NotesTip() (string, error) {
+	tip, err := s.getCommitHash("refs/notes/" + s.options.NotesRef)
+	if err != nil {
+		if hashes, listErr := getNotesHashes(s.git, s.options.NotesRef); listErr == nil && len(hashes) == 0 {
+			return "", nil
+		}
+		return "", err
+	}
+	return tip, nil
+}
+
+// ResetNotes points the notes ref back at the tip returned by NotesTip, discarding any changes made to the notes
+// since. This includes notes merged on fetch, which are merged again on the next fetch
+func (s *Store) ResetNotes(tip string) error {
+	log.WithField("tip", tip).Debug("Resetting notes...")
+
+	var out string
+	var err error
+	if tip == "" {
+		out, err = s.git.DeleteNotesRef(s.options.NotesRef)
+	} else {
+		out, err = s.git.UpdateNotesRef(s.options.NotesRef, tip)
+	}
+	if err != nil {
+		return convertGitOutputToError(out, err)
+	}
+	return nil
+}
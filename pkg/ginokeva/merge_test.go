@@ -0,0 +1,161 @@
+package ginokeva
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/gitfake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitEvents(t *testing.T) {
+	var (
+		valueBaz  = "baz"
+		setFooBaz = event.Event{EventType: event.Set, Key: event.TestDataFoo, Value: &valueBaz}
+		unsetKey  = event.TestDataUnsetKey
+	)
+
+	testCases := []struct {
+		name          string
+		local         []event.Event
+		remote        []event.Event
+		wantLocalNew  []event.Event
+		wantRemoteNew []event.Event
+		wantCommon    []event.Event
+	}{
+		{
+			name:         "Only local has new events",
+			local:        []event.Event{setFooBaz, event.TestDataSetKeyValue},
+			remote:       []event.Event{event.TestDataSetKeyValue},
+			wantLocalNew: []event.Event{setFooBaz},
+			wantCommon:   []event.Event{event.TestDataSetKeyValue},
+		},
+		{
+			name:          "Both have new events",
+			local:         []event.Event{setFooBaz, event.TestDataSetKeyValue},
+			remote:        []event.Event{unsetKey, event.TestDataSetKeyValue},
+			wantLocalNew:  []event.Event{setFooBaz},
+			wantRemoteNew: []event.Event{unsetKey},
+			wantCommon:    []event.Event{event.TestDataSetKeyValue},
+		},
+		{
+			name:          "Events both have as new events are local only",
+			local:         []event.Event{setFooBaz, event.TestDataSetFooBar},
+			remote:        []event.Event{unsetKey, setFooBaz},
+			wantLocalNew:  []event.Event{setFooBaz, event.TestDataSetFooBar},
+			wantRemoteNew: []event.Event{unsetKey},
+		},
+		{
+			name:          "No local note",
+			local:         nil,
+			remote:        []event.Event{unsetKey},
+			wantRemoteNew: []event.Event{unsetKey},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localNew, remoteNew, common, err := splitEvents(tc.local, tc.remote)
+			assert.NoError(t, err)
+
+			// Compare in order, not distinguishing nil and empty
+			orEmpty := func(events []event.Event) []event.Event { return append([]event.Event{}, events...) }
+			assert.Equal(t, orEmpty(tc.wantLocalNew), orEmpty(localNew))
+			assert.Equal(t, orEmpty(tc.wantRemoteNew), orEmpty(remoteNew))
+			assert.Equal(t, orEmpty(tc.wantCommon), orEmpty(common))
+		})
+	}
+}
+
+func TestFetchMergesDivergedNotes(t *testing.T) {
+	newClones := func() (a, b *gitfake.Repository) {
+		remote := gitfake.NewRemote()
+		a = remote.Clone()
+		a.Commit("First")
+		a.PushBranch(gitfake.DefaultBranch)
+		return a, remote.Clone()
+	}
+
+	t.Run("Unpushed local changes are ordered after upstream changes", func(t *testing.T) {
+		a, b := newClones()
+		alice, bob := NewStore(a, Options{}), NewStore(b, Options{})
+
+		assert.NoError(t, alice.Set("foo", "alice"))
+		assert.NoError(t, alice.Set("shared", "alice"))
+		assert.NoError(t, alice.Push())
+		assert.NoError(t, bob.Set("bar", "bob"))
+		assert.NoError(t, bob.Set("shared", "bob"))
+
+		assert.NoError(t, bob.Fetch())
+		values, err := bob.List()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]Value{"foo": "alice", "bar": "bob", "shared": "bob"}, values.Iterate())
+
+		assert.NoError(t, bob.Push(), "Merged notes fast-forward the remote")
+		assert.NoError(t, alice.Fetch())
+		assert.Equal(t, a.Notes(DefaultNotesRef), b.Notes(DefaultNotesRef))
+	})
+
+	t.Run("Fetching again after merging changes nothing", func(t *testing.T) {
+		a, b := newClones()
+		alice, bob := NewStore(a, Options{}), NewStore(b, Options{})
+
+		assert.NoError(t, alice.Set("foo", "alice"))
+		assert.NoError(t, alice.Push())
+		assert.NoError(t, bob.Set("bar", "bob"))
+		assert.NoError(t, bob.Fetch())
+		merged := b.Notes(DefaultNotesRef)
+
+		assert.NoError(t, bob.Fetch())
+		assert.Equal(t, merged, b.Notes(DefaultNotesRef))
+		assert.Empty(t, b.Notes(DefaultNotesRef+"-fetched/origin"), "Temporary notes ref is removed")
+	})
+
+	t.Run("Checkpoints which don't account for the other side are dropped", func(t *testing.T) {
+		a, b := newClones()
+		alice, bob := NewStore(a, Options{}), NewStore(b, Options{})
+
+		assert.NoError(t, alice.Set("foo", "alice"))
+		assert.NoError(t, alice.Push())
+		assert.NoError(t, bob.Set("bar", "bob"))
+		_, _, err := bob.Compact()
+		assert.NoError(t, err)
+
+		assert.NoError(t, bob.Fetch())
+		values, err := bob.List()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]Value{"foo": "alice", "bar": "bob"}, values.Iterate())
+	})
+}
+
+func TestResetNotes(t *testing.T) {
+	t.Run("Changes made after taking the tip are discarded", func(t *testing.T) {
+		repo := gitfake.NewRepository()
+		repo.Commit("First")
+		store := NewStore(repo, Options{})
+
+		assert.NoError(t, store.Set("foo", "bar"))
+		tip, err := store.NotesTip()
+		assert.NoError(t, err)
+		assert.NoError(t, store.Set("foo", "baz"))
+
+		assert.NoError(t, store.ResetNotes(tip))
+		value, err := store.Get("foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", value)
+	})
+
+	t.Run("Without notes the tip is empty and resetting removes the notes", func(t *testing.T) {
+		repo := gitfake.NewRepository()
+		repo.Commit("First")
+		store := NewStore(repo, Options{})
+
+		tip, err := store.NotesTip()
+		assert.NoError(t, err)
+		assert.Empty(t, tip)
+		assert.NoError(t, store.Set("foo", "bar"))
+
+		assert.NoError(t, store.ResetNotes(tip))
+		assert.Empty(t, repo.Notes(DefaultNotesRef))
+	})
+}
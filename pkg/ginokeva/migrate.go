@@ -0,0 +1,75 @@
+package ginokeva
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// NoteMigration represents the migration of a single note to the current version of the note schema
+type NoteMigration struct {
+	Hash        string
+	FromVersion int
+}
+
+// Migrate rewrites every note in the notes ref which isn't in the current version of the note schema, including
+// the legacy format from before events were introduced. A legacy note holds all key/values at its commit, so it's
+// rewritten as a checkpoint. With dryRun, the notes are only returned
+func (s *Store) Migrate(dryRun bool) (migrations []NoteMigration, err error) {
+	migrations = []NoteMigration{}
+
+	blobs, err := getNotesBlobs(s.git, s.options.NotesRef)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make([]string, 0, len(blobs))
+	for n := range blobs {
+		notes = append(notes, n)
+	}
+	sort.Strings(notes)
+
+	migrated := map[string][]event.Event{}
+	err = s.git.ReadBlobs(func(readBlob func(hash string) (string, error)) error {
+		for _, n := range notes {
+			note, err := readBlob(blobs[n])
+			if err != nil {
+				return err
+			}
+
+			events, version, err := event.Decode(note)
+			if err != nil {
+				return fmt.Errorf("cannot decode note on %v: %w", n, err)
+			}
+
+			if version != event.CurrentVersion {
+				migrations = append(migrations, NoteMigration{Hash: n, FromVersion: version})
+				migrated[n] = events
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range migrations {
+		log.WithFields(log.Fields{
+			"hash":    m.Hash,
+			"version": m.FromVersion,
+			"dryRun":  dryRun,
+		}).Debug("Migrating note...")
+
+		if !dryRun {
+			events := migrated[m.Hash]
+			err = s.persistEvents(m.Hash, &events)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return migrations, nil
+}
@@ -0,0 +1,461 @@
+package ginokeva
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/philips-software/gino-keva/internal/event"
+)
+
+func (s *Store) getCommitHash(rev string) (string, error) {
+	out, err := s.git.RevParse(rev)
+	if err != nil {
+		return "", convertGitOutputToError(out, err)
+	}
+
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (s *Store) getEvents(commitHash string) (*[]event.Event, error) {
+	log.WithField("hash", commitHash).Debug("Retrieving events from git note...")
+	events, err := s.getEventsFromNote(commitHash)
+
+	if _, ok := err.(*NoNotePresent); ok {
+		log.WithField("notesRef", s.options.NotesRef).Debug("No git note present yet")
+		err = nil
+	}
+
+	return &events, err
+}
+
+func (s *Store) persistEvents(commitHash string, events *[]event.Event) error {
+	noteText, err := event.Marshal(events)
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"hash":     commitHash,
+		"noteText": noteText,
+	}).Debug("Persisting new note text...")
+
+	{
+		out, err := s.git.NotesAdd(s.options.NotesRef, commitHash, noteText)
+		if err != nil {
+			return convertGitOutputToError(out, err)
+		}
+	}
+
+	return nil
+}
+
+// addEvents adds the new events to the note of the commit, and writes a checkpoint when one is due. Returns
+// NamespaceConflict if the events would use a key both as a value and as a namespace
+func (s *Store) addEvents(rev string, newEvents []event.Event) (commitHash string, err error) {
+	err = s.checkNamespaces(rev, newEvents)
+	if err != nil {
+		return "", err
+	}
+
+	commitHash, err = s.persistNewEvents(rev, newEvents)
+	if err != nil {
+		return "", err
+	}
+
+	if s.options.CheckpointInterval > 0 {
+		err = s.checkpointIfDue(rev, s.options.CheckpointInterval)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return commitHash, nil
+}
+
+// checkNamespaces checks the keys the new events assign a value to against the current snapshot (including expired
+// values), so no key ends up being used both as a value and as a namespace
+func (s *Store) checkNamespaces(rev string, newEvents []event.Event) error {
+	assigns := false
+	for _, e := range newEvents {
+		assigns = assigns || assignsValue(e)
+	}
+	if !assigns {
+		return nil
+	}
+
+	values, err := s.calculateKeyValuesWithExpired(rev, true)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range newEvents { // Iterate from old to new
+		if e.EventType == event.Unset {
+			values.Remove(e.Key)
+		} else if assignsValue(e) {
+			err = values.checkNamespaceConflict(e.Key)
+			if err != nil {
+				return err
+			}
+			values.Add(e.Key, "")
+		}
+	}
+
+	return nil
+}
+
+func assignsValue(e event.Event) bool {
+	return e.EventType != event.Unset && e.EventType != event.Checkpoint
+}
+
+func (s *Store) persistNewEvents(rev string, newEvents []event.Event) (commitHash string, err error) {
+	commitHash, err = s.getCommitHash(rev)
+	if err != nil {
+		return "", err
+	}
+
+	events, err := s.getEvents(commitHash)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range newEvents { // Iterate from old to new (newest event ends up in front)
+		*events = event.AddNewEvent(events, &newEvents[i])
+	}
+
+	err = s.persistEvents(commitHash, events)
+	if err != nil {
+		return "", err
+	}
+
+	return commitHash, nil
+}
+
+// walkAction tells walkNotes how to continue after a note
+type walkAction int
+
+const (
+	walkContinue      walkAction = iota // Continue with the next note
+	walkStop                            // Stop the walk
+	walkSkipAncestors                   // Continue, but skip the notes of the ancestors of the commit of the note
+)
+
+// walkNotes calls fn with the events of each note in the history of rev (newest note first), until fn stops the walk.
+// If fn skips the ancestors of a note, the walk continues with the notes of commits which aren't ancestors of it, like
+// those of a merged branch. The history is streamed and each note is read once its commit is reached, so if fn stops
+// early the older part of the history is never listed nor read
+func (s *Store) walkNotes(rev string, fn func(note string, events []event.Event) (walkAction, error)) error {
+	blobs, err := getNotesBlobs(s.git, s.options.NotesRef)
+	if err != nil {
+		return err
+	}
+	if len(blobs) == 0 {
+		log.WithField("ref", s.options.NotesRef).Warning("No prior notes found")
+		return nil
+	}
+
+	return s.git.ReadBlobs(func(readBlob func(hash string) (string, error)) error {
+		exclude := []string{}
+		for {
+			skipFrom := ""
+			err := s.walkNotesOf(rev, exclude, blobs, readBlob, func(n string, events []event.Event) (bool, error) {
+				action, err := fn(n, events)
+				if action == walkSkipAncestors {
+					skipFrom = n
+				}
+				return action == walkContinue, err
+			})
+			if err != nil || skipFrom == "" {
+				return err
+			}
+
+			log.WithField("hash", skipFrom).Debug("Skipping notes of ancestors")
+			exclude = append(exclude, skipFrom)
+		}
+	})
+}
+
+// walkNotesOf calls fn with the events of each note of the commits reachable from rev, but not from the excluded
+// commits, until fn returns false. The note blob of each commit is taken from blobs, and removed once read so the
+// note isn't read again. Reading stops at the first note in the legacy format
+func (s *Store) walkNotesOf(rev string, exclude []string, blobs map[string]string, readBlob func(hash string) (string, error), fn func(note string, events []event.Event) (bool, error)) error {
+	var walkErr error
+	out, err := s.git.LogCommitsEach(rev, exclude, func(hash string) bool {
+		blob, ok := blobs[hash]
+		if !ok {
+			return true
+		}
+		delete(blobs, hash)
+
+		log.WithField("hash", hash).Debug("Get events from note")
+		noteText, err := readBlob(blob)
+		if err != nil {
+			walkErr = err
+			return false
+		}
+
+		events, err := parseNote(hash, noteText)
+		if _, ok := err.(*event.NoEventsInNote); ok {
+			log.WithField("hash", hash).Warning("Ignoring notes from here on since they use the legacy format. Run 'gino-keva migrate' to convert them")
+			return false
+		} else if err != nil {
+			walkErr = err
+			return false
+		}
+
+		var next bool
+		next, walkErr = fn(hash, events)
+		return next && walkErr == nil
+	})
+	if err != nil {
+		return convertGitOutputToError(out, err)
+	}
+
+	return walkErr
+}
+
+func containsCheckpoint(events []event.Event) bool {
+	for _, e := range events {
+		if e.EventType == event.Checkpoint {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) getEventsFromNote(note string) (events []event.Event, err error) {
+	var noteText string
+	{
+		out, err := s.git.NotesShow(s.options.NotesRef, note)
+		if err != nil {
+			return nil, convertGitOutputToError(out, err)
+		}
+		noteText = out
+	}
+
+	return parseNote(note, noteText)
+}
+
+// parseNote returns the events stored in the note on the commit
+func parseNote(note string, noteText string) (events []event.Event, err error) {
+	events = []event.Event{}
+
+	if noteText != "" {
+		log.WithField("rawText", noteText).Debug("Unmarshalling...")
+		err = event.Unmarshal(noteText, &events)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range events {
+		events[i].Commit = note
+	}
+
+	return events, nil
+}
+
+// Fetch fetches the notes from each remote in order. A remote without notes isn't an error. If the local notes
+// diverged from the notes of a remote, both are merged, so unpushed local changes are kept. Failures are reported per
+// remote; only if the notes couldn't be fetched from any of the remotes, FetchFailed is returned
+func (s *Store) Fetch() error {
+	failed := map[string]error{}
+	for _, remote := range s.options.Remotes {
+		logger := log.WithField("remote", remote)
+
+		err := s.fetchFrom(remote)
+		if err != nil {
+			logger.WithError(err).Warning("Failed to fetch notes")
+			failed[remote] = err
+		} else if len(s.options.Remotes) > 1 {
+			logger.Info("Fetched notes")
+		}
+	}
+
+	if len(failed) == len(s.options.Remotes) {
+		return &FetchFailed{Errors: failed}
+	}
+	return nil
+}
+
+func (s *Store) fetchFrom(remote string) (err error) {
+	log.WithField("remote", remote).Debug("Fetching notes...")
+	defer log.Debug("Done.")
+
+	out, errorCode := s.git.FetchNotes(remote, s.options.NotesRef, false)
+	err = convertGitOutputToError(out, errorCode)
+
+	if _, ok := err.(*UpstreamChanged); ok {
+		log.WithField("remote", remote).Debug("Local notes diverged")
+		err = s.mergeFrom(remote)
+	}
+
+	if _, ok := err.(*NoRemoteRef); ok {
+		log.WithFields(log.Fields{
+			"remote":   remote,
+			"notesRef": s.options.NotesRef,
+		}).Debug("Couldn't find remote ref. Nothing fetched")
+		err = nil
+	}
+
+	return err
+}
+
+// Prune removes the notes of commits which no longer exist
+func (s *Store) Prune() error {
+	log.Debug("Pruning notes...")
+	defer log.Debug("Done.")
+
+	out, errorCode := s.git.NotesPrune(s.options.NotesRef)
+	return convertGitOutputToError(out, errorCode)
+}
+
+// maxPushAttempts is the number of times the notes are pushed to a remote which rejected them, while other remotes
+// accepted them
+const maxPushAttempts = 3
+
+// Push pushes the notes to each remote. Returns UpstreamChanged if the notes of every remote changed since they were
+// fetched; nothing is pushed then, so the changes can be made again after fetching. If only some remotes rejected the
+// notes, the changes are already published, so the notes of those remotes are merged and pushed to them again instead.
+// If a conditional set no longer holds for the notes of such a remote, PreconditionFailed is returned without pushing
+// to it. Returns PushFailed if pushing to any remote failed otherwise
+func (s *Store) Push() error {
+	rejected, failed := s.pushToEach(s.options.Remotes)
+
+	if len(rejected) > 0 && len(rejected)+len(failed) < len(s.options.Remotes) {
+		for attempt := 1; attempt < maxPushAttempts && len(rejected) > 0; attempt++ {
+			log.WithField("remotes", rejected).Info("Upstream has changed in the meanwhile. Merging and pushing again")
+
+			var pending []string
+			for _, remote := range rejected {
+				err := s.fetchFrom(remote)
+				if _, ok := err.(*PreconditionFailed); ok {
+					log.WithField("remote", remote).Warning("Not pushing notes, since a conditional set no longer holds for the notes of the remote")
+					return err
+				} else if err != nil {
+					log.WithField("remote", remote).WithError(err).Warning("Failed to fetch notes")
+					failed[remote] = err
+				} else {
+					pending = append(pending, remote)
+				}
+			}
+
+			var retryFailed map[string]error
+			rejected, retryFailed = s.pushToEach(pending)
+			for remote, err := range retryFailed {
+				failed[remote] = err
+			}
+		}
+
+		for _, remote := range rejected {
+			failed[remote] = &UpstreamChanged{}
+		}
+		rejected = nil
+	}
+
+	if len(failed) > 0 {
+		return &PushFailed{Errors: failed}
+	}
+	if len(rejected) > 0 {
+		return &UpstreamChanged{}
+	}
+
+	s.preconditions = nil // Every remote holds the conditional sets now
+	return nil
+}
+
+// pushToEach pushes the notes to each of the remotes. Returns the remotes which rejected the notes since upstream
+// changed, and the errors of the remotes to which pushing failed otherwise
+func (s *Store) pushToEach(remotes []string) (rejected []string, failed map[string]error) {
+	failed = map[string]error{}
+	for _, remote := range remotes {
+		logger := log.WithField("remote", remote)
+
+		err := s.pushTo(remote)
+		if _, ok := err.(*UpstreamChanged); ok {
+			logger.Debug("Upstream has changed in the meanwhile")
+			rejected = append(rejected, remote)
+		} else if err != nil {
+			logger.WithError(err).Warning("Failed to push notes")
+			failed[remote] = err
+		} else if len(s.options.Remotes) > 1 {
+			logger.Info("Pushed notes")
+		}
+	}
+
+	return rejected, failed
+}
+
+func (s *Store) pushTo(remote string) error {
+	log.WithField("remote", remote).Debug("Pushing notes...")
+	defer log.Debug("Done.")
+
+	out, errorCode := s.git.PushNotes(remote, s.options.NotesRef)
+	return convertGitOutputToError(out, errorCode)
+}
+
+// CommitInfo holds the metadata of a single commit
+type CommitInfo struct {
+	Hash    string `json:"hash"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+}
+
+// Commit returns the metadata of the commit the store operates on
+func (s *Store) Commit() (*CommitInfo, error) {
+	return s.getCommitInfo(s.rev)
+}
+
+func (s *Store) getCommitInfo(hash string) (*CommitInfo, error) {
+	out, err := s.git.CommitInfo(hash)
+	if err != nil {
+		return nil, convertGitOutputToError(out, err)
+	}
+
+	fields := strings.SplitN(strings.TrimSuffix(out, "\n"), "\t", 3)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected commit info for %v: %v", hash, out)
+	}
+
+	return &CommitInfo{
+		Hash:    fields[0],
+		Date:    fields[1],
+		Subject: fields[2],
+	}, nil
+}
+
+func getNotesHashes(gitWrapper GitWrapper, notesRef string) (hashList []string, err error) {
+	out, err := gitWrapper.NotesList(notesRef)
+	if err != nil {
+		return nil, convertGitOutputToError(out, err)
+	}
+
+	if out == "" {
+		hashList = []string{}
+	} else {
+		out := strings.TrimSuffix(out, "\n")
+		lines := strings.Split(out, "\n")
+		for _, line := range lines {
+			hashes := strings.Split(line, " ")
+			hashList = append(hashList, hashes[1])
+		}
+	}
+	return hashList, nil
+}
+
+// getNotesBlobs returns the hash of the note blob of each commit with a note (commit hash -> blob hash)
+func getNotesBlobs(gitWrapper GitWrapper, notesRef string) (blobs map[string]string, err error) {
+	out, err := gitWrapper.NotesList(notesRef)
+	if err != nil {
+		return nil, convertGitOutputToError(out, err)
+	}
+
+	blobs = map[string]string{}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if hashes := strings.Split(line, " "); len(hashes) == 2 {
+			blobs[hashes[1]] = hashes[0]
+		}
+	}
+	return blobs, nil
+}
@@ -0,0 +1,282 @@
+package ginokeva
+
+import (
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/philips-software/gino-keva/internal/event"
+)
+
+func (s *Store) calculateKeyValues(rev string) (values *Values, err error) {
+	return s.calculateKeyValuesWithExpired(rev, false)
+}
+
+// calculateKeyValuesWithExpired optionally includes values which are expired
+func (s *Store) calculateKeyValuesWithExpired(rev string, includeExpired bool) (values *Values, err error) {
+	return s.replayNotes(rev, newReplay(s.now(), includeExpired), nil)
+}
+
+// calculateKeyValuesOf only calculates the values of the provided keys. Replay stops as soon as these keys are
+// resolved, so only the most recent part of the history is read
+func (s *Store) calculateKeyValuesOf(rev string, keys []string) (values *Values, err error) {
+	values, err = s.replayNotes(rev, newReplay(s.now(), false), keys)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		wanted[k] = struct{}{}
+	}
+
+	// Other keys are not necessarily resolved, so drop them
+	return values.Filter(func(k string) bool {
+		_, ok := wanted[k]
+		return ok
+	}), nil
+}
+
+// replayNotes replays the notes in the history of rev, until the provided keys are resolved. If no keys are
+// provided, replay continues until the end of the history is reached. A checkpoint holds the key/values of all its
+// ancestors, so the notes of these are skipped. Notes of commits which aren't its ancestors (like those of a merged
+// branch) are still replayed
+func (s *Store) replayNotes(rev string, r *replay, keys []string) (values *Values, err error) {
+	err = s.walkNotes(rev, func(n string, events []event.Event) (walkAction, error) {
+		for _, e := range events { // Iterate from new to old (newest event in front)
+			err := r.apply(e)
+			if err != nil {
+				return walkStop, err
+			}
+
+			if e.EventType == event.Checkpoint {
+				log.WithField("hash", n).Debug("Checkpoint found, so notes of its ancestors are not needed")
+				return walkSkipAncestors, nil
+			}
+		}
+
+		if keys != nil && r.resolved(keys) {
+			log.WithField("hash", n).Debug("All keys resolved, so older notes are not needed")
+			return walkStop, nil
+		}
+		return walkContinue, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.values()
+}
+
+// keyReplayState holds the state of a single key while replaying events from new to old
+type keyReplayState struct {
+	resolved bool // A set or unset event was encountered, so older events no longer matter
+	unset    bool
+	value    Value
+	metadata valueMetadata // Metadata of the newest event for the key
+	delta    int64         // Sum of all incr/decr events newer than the set/unset event
+	hasDelta bool
+	items    map[string]bool // Per item, whether the newest append/remove event added it
+	appended []string        // Appended items from new to old
+}
+
+// calculateKeyValuesFromEvents replays the events (newest event in front), evaluating expiry against currentTime
+func calculateKeyValuesFromEvents(events []event.Event, currentTime time.Time, includeExpired bool) (values *Values, err error) {
+	r := newReplay(currentTime, includeExpired)
+	for _, e := range events { // Iterate from new to old (newest event in front)
+		if r.complete {
+			break
+		}
+		err = r.apply(e)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r.values()
+}
+
+// replay holds the state of all keys while replaying events from new to old
+type replay struct {
+	states         map[string]*keyReplayState
+	order          []string // Keys in order of last modification
+	currentTime    time.Time
+	includeExpired bool
+	complete       bool // A checkpoint was applied, so older events of the same note don't matter
+}
+
+func newReplay(currentTime time.Time, includeExpired bool) *replay {
+	return &replay{
+		states:         map[string]*keyReplayState{},
+		order:          []string{},
+		currentTime:    currentTime,
+		includeExpired: includeExpired,
+	}
+}
+
+func (r *replay) state(key string, commit string, origin *event.Metadata) *keyReplayState {
+	s, ok := r.states[key]
+	if !ok {
+		s = &keyReplayState{metadata: valueMetadata{commit: commit, origin: origin}}
+		r.states[key] = s
+		r.order = append(r.order, key)
+	}
+	return s
+}
+
+// apply the event, which must be older than all events applied before. Returns UnknownEventType if the event type
+// isn't known
+func (r *replay) apply(e event.Event) error {
+	if e.EventType == event.Checkpoint {
+		for _, entry := range *e.Snapshot {
+			s := r.state(entry.Key, entry.Commit, entry.Metadata)
+			if s.resolved {
+				continue
+			}
+
+			if entry.Unset {
+				s.resolved = true
+				s.unset = true
+			} else {
+				s.resolve(entry.Value, entry.ValueType, entry.ExpiresAt, r.currentTime, r.includeExpired)
+			}
+		}
+		r.complete = true // The checkpoint holds all key/values of its ancestors, so older events don't matter
+		return nil
+	}
+
+	s := r.state(e.Key, e.Commit, e.Metadata)
+	if s.resolved {
+		return nil
+	}
+
+	switch e.EventType {
+	case event.Set:
+		s.resolve(*e.Value, e.ValueType, e.ExpiresAt, r.currentTime, r.includeExpired)
+	case event.Unset:
+		s.resolved = true
+		s.unset = true
+	case event.Incr:
+		s.delta += *e.Delta
+		s.hasDelta = true
+	case event.Decr:
+		s.delta -= *e.Delta
+		s.hasDelta = true
+	case event.Append, event.Remove:
+		if s.items == nil {
+			s.items = map[string]bool{}
+		}
+		if _, ok := s.items[*e.Value]; ok {
+			return nil // A newer event already decided on this item
+		}
+		s.items[*e.Value] = e.EventType == event.Append
+		if e.EventType == event.Append {
+			s.appended = append(s.appended, *e.Value)
+		}
+	default:
+		return &UnknownEventType{EventType: e.EventType}
+	}
+
+	return nil
+}
+
+// resolved returns true if older events cannot change the values of the keys anymore
+func (r *replay) resolved(keys []string) bool {
+	for _, k := range keys {
+		if s, ok := r.states[k]; !ok || !s.resolved {
+			return false
+		}
+	}
+	return true
+}
+
+// values returns the values of all keys replayed so far. A key whose value cannot be calculated (like an incremented
+// value which isn't an integer, or items appended to a value which isn't a list) doesn't affect the other keys; its
+// error is recorded in the collection instead
+func (r *replay) values() (values *Values, err error) {
+	v := NewValues()
+	for _, k := range r.order {
+		s := r.states[k]
+		value, metadata := s.value, s.metadata
+
+		if s.items != nil {
+			items, err := s.replayItems(k)
+			if err != nil {
+				v.addErr(k, err)
+				continue
+			}
+
+			value = Value(event.MarshalList(items))
+			metadata.valueType = event.List
+
+			if s.hasDelta {
+				v.addErr(k, &NotAnInteger{Key: k, Value: string(value)})
+				continue
+			}
+		} else if s.hasDelta {
+			var base int64
+			if s.resolved && !s.unset {
+				base, err = strconv.ParseInt(string(s.value), 10, 64)
+				if err != nil {
+					v.addErr(k, &NotAnInteger{Key: k, Value: string(s.value)})
+					continue
+				}
+			}
+
+			value = Value(strconv.FormatInt(base+s.delta, 10))
+			metadata.valueType = event.Int
+		} else if s.unset {
+			v.unset[k] = metadata
+			continue
+		}
+
+		v.add(k, value, metadata)
+	}
+
+	return v, nil
+}
+
+// resolve sets the value of the key, which makes older events for the key obsolete
+func (s *keyReplayState) resolve(value string, valueType event.ValueType, expiresAt *time.Time, currentTime time.Time, includeExpired bool) {
+	s.resolved = true
+	if expiresAt != nil && !expiresAt.After(currentTime) && !includeExpired {
+		s.unset = true // An expired value is treated as if it was unset
+		return
+	}
+
+	s.value = Value(value)
+	s.metadata.valueType = valueType
+	s.metadata.expiresAt = expiresAt
+}
+
+// replayItems applies the append/remove events to the list value of the set event (if any)
+func (s *keyReplayState) replayItems(key string) (items []string, err error) {
+	base := []string{}
+	if s.resolved && !s.unset {
+		base, err = event.ParseList(string(s.value))
+		if err != nil {
+			return nil, &NotAList{Key: key, Value: string(s.value)}
+		}
+	}
+
+	items = []string{}
+	present := map[string]bool{}
+	for _, item := range base {
+		if added, ok := s.items[item]; (ok && !added) || present[item] {
+			continue
+		}
+		present[item] = true
+		items = append(items, item)
+	}
+
+	for i := len(s.appended) - 1; i >= 0; i-- { // Iterate from old to new, so the newest item ends up last
+		item := s.appended[i]
+		if !present[item] {
+			present[item] = true
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,331 @@
+package ginokeva
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/gitfake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNotesHashes(t *testing.T) {
+	testCases := []struct {
+		name  string
+		notes [][]event.Event
+	}{
+		{
+			name:  "Get notes hashes - no notes",
+			notes: [][]event.Event{},
+		},
+		{
+			name:  "Get notes hashes - 1 note",
+			notes: [][]event.Event{{event.TestDataSetFooBar}},
+		},
+		{
+			name:  "Get notes hashes - 2 notes",
+			notes: [][]event.Event{{event.TestDataSetFooBar}, {event.TestDataSetKeyValue}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newTestRepository(t, tc.notes...)
+			wanted := []string{}
+			for hash := range repo.Notes(DefaultNotesRef) {
+				wanted = append(wanted, hash)
+			}
+
+			hashes, err := getNotesHashes(repo, DefaultNotesRef)
+
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, wanted, hashes)
+		})
+	}
+}
+
+func TestCalculateKeyValues(t *testing.T) {
+	listEUAndAsia := `["eu","asia"]`
+
+	testCases := []struct {
+		name   string
+		events [][]event.Event
+		wanted map[string]Value
+	}{
+		{
+			name:   "No notes",
+			events: [][]event.Event{{}},
+			wanted: map[string]Value{},
+		},
+		{
+			name: "Note with single set event",
+			events: [][]event.Event{
+				{event.TestDataSetFooBar},
+			},
+			wanted: map[string]Value{
+				event.TestDataFoo: Value(event.TestDataBar),
+			},
+		},
+		{
+			name: "Note with 2 set event",
+			events: [][]event.Event{
+				{event.TestDataSetFooBar, event.TestDataSetKeyValue},
+			},
+			wanted: map[string]Value{
+				event.TestDataFoo: Value(event.TestDataBar),
+				event.TestDataKey: Value(event.TestDataValue),
+			},
+		},
+		{
+			name: "Overwrite value in same note",
+			events: [][]event.Event{
+				{event.TestDataSetKeyOtherValue, event.TestDataSetKeyValue},
+			},
+			wanted: map[string]Value{
+				event.TestDataKey: Value(event.TestDataOtherValue),
+			},
+		},
+		{
+			name: "Set and unset value in same note",
+			events: [][]event.Event{
+				{event.TestDataUnsetKey, event.TestDataSetKeyValue},
+			},
+			wanted: map[string]Value{},
+		},
+		{
+			name: "Set events across 2 notes",
+			events: [][]event.Event{
+				{event.TestDataSetFooBar},
+				{event.TestDataSetKeyValue},
+			},
+			wanted: map[string]Value{
+				event.TestDataFoo: Value(event.TestDataBar),
+				event.TestDataKey: Value(event.TestDataValue),
+			},
+		},
+		{
+			name: "Overwrite value across 2 notes",
+			events: [][]event.Event{
+				{event.TestDataSetKeyOtherValue},
+				{event.TestDataSetKeyValue},
+			},
+			wanted: map[string]Value{
+				event.TestDataKey: Value(event.TestDataOtherValue),
+			},
+		},
+		{
+			name: "Incr counter without prior value",
+			events: [][]event.Event{
+				{event.TestDataIncrCounter5},
+			},
+			wanted: map[string]Value{
+				event.TestDataCounter: Value("5"),
+			},
+		},
+		{
+			name: "Incr counter across 2 notes",
+			events: [][]event.Event{
+				{event.TestDataIncrCounter5},
+				{event.TestDataSetCounterInt12},
+			},
+			wanted: map[string]Value{
+				event.TestDataCounter: Value("17"),
+			},
+		},
+		{
+			name: "Incr and decr counter in same note",
+			events: [][]event.Event{
+				{event.TestDataDecrCounter5, event.TestDataDecrCounter5, event.TestDataIncrCounter5, event.TestDataSetCounterInt12},
+			},
+			wanted: map[string]Value{
+				event.TestDataCounter: Value("7"),
+			},
+		},
+		{
+			name: "Set overrides older incr",
+			events: [][]event.Event{
+				{event.TestDataSetCounterInt12},
+				{event.TestDataIncrCounter5},
+			},
+			wanted: map[string]Value{
+				event.TestDataCounter: Value(event.TestData12),
+			},
+		},
+		{
+			name: "Append items across 2 notes",
+			events: [][]event.Event{
+				{event.TestDataAppendRegionsUS},
+				{event.TestDataAppendRegionsEU},
+			},
+			wanted: map[string]Value{
+				event.TestDataRegions: Value(`["eu","us"]`),
+			},
+		},
+		{
+			name: "Remove item appended in older note",
+			events: [][]event.Event{
+				{event.TestDataRemoveRegionsEU},
+				{event.TestDataAppendRegionsUS, event.TestDataAppendRegionsEU},
+			},
+			wanted: map[string]Value{
+				event.TestDataRegions: Value(`["us"]`),
+			},
+		},
+		{
+			name: "Re-append removed item",
+			events: [][]event.Event{
+				{event.TestDataAppendRegionsEU, event.TestDataRemoveRegionsEU},
+				{event.TestDataAppendRegionsEU},
+			},
+			wanted: map[string]Value{
+				event.TestDataRegions: Value(`["eu"]`),
+			},
+		},
+		{
+			name: "Append and remove items of set list",
+			events: [][]event.Event{
+				{event.TestDataAppendRegionsUS, event.TestDataRemoveRegionsEU},
+				{{EventType: event.Set, Key: event.TestDataRegions, Value: &listEUAndAsia, ValueType: event.List}},
+			},
+			wanted: map[string]Value{
+				event.TestDataRegions: Value(`["asia","us"]`),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newTestRepository(t, tc.events...)
+
+			got, err := NewStore(repo, Options{}).calculateKeyValues("HEAD")
+
+			assert.NoError(t, err)
+			assert.Truef(t, reflect.DeepEqual(got.values, tc.wanted), "Got %v, wanted %v", got.values, tc.wanted)
+		})
+	}
+}
+
+func TestCalculateKeyValuesNotAnInteger(t *testing.T) {
+	events := []event.Event{event.TestDataIncrCounter5, {
+		EventType: event.Set,
+		Key:       event.TestDataCounter,
+		Value:     &event.TestDataValue,
+	}}
+
+	values, err := calculateKeyValuesFromEvents(events, time.Now(), false)
+
+	assert.NoError(t, err)
+	assert.False(t, values.HasKey(event.TestDataCounter))
+	assert.IsType(t, &NotAnInteger{}, values.Err(event.TestDataCounter))
+}
+
+func TestNotAnIntegerOnlyAffectsThatKey(t *testing.T) {
+	repo := gitfake.NewRepository()
+	store := NewStore(repo, Options{})
+
+	repo.Commit("Base")
+	assert.NoError(t, store.Set("other", "1"))
+	assert.NoError(t, repo.Branch("feature"))
+	repo.Commit("Main")
+	assert.NoError(t, store.Set("counter", "foo"))
+
+	// The counter is incremented on the branch, which is unaware of it being set to a string on main
+	assert.NoError(t, repo.Checkout("feature"))
+	repo.Commit("Feature")
+	_, err := store.Incr("counter", 1)
+	assert.NoError(t, err)
+	_, err = repo.Merge(gitfake.DefaultBranch, "Merge main")
+	assert.NoError(t, err)
+
+	values, err := store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other"}, values.Keys())
+	assert.IsType(t, &NotAnInteger{}, values.Err("counter"))
+
+	value, err := store.Get("other")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+	assert.NoError(t, store.Set("other", "2"))
+
+	_, err = store.Get("counter")
+	assert.IsType(t, &NotAnInteger{}, err)
+	_, err = store.Incr("counter", 1)
+	assert.IsType(t, &NotAnInteger{}, err)
+}
+
+func TestCalculateKeyValuesNotAList(t *testing.T) {
+	events := []event.Event{event.TestDataAppendRegionsEU, {
+		EventType: event.Set,
+		Key:       event.TestDataRegions,
+		Value:     &event.TestDataValue,
+	}}
+
+	events = append([]event.Event{event.TestDataSetFooBar}, events...)
+
+	values, err := calculateKeyValuesFromEvents(events, time.Now(), false)
+
+	assert.NoError(t, err)
+	assert.False(t, values.HasKey(event.TestDataRegions))
+	assert.IsType(t, &NotAList{}, values.Err(event.TestDataRegions))
+	assert.Equal(t, Value(event.TestDataBar), values.Get(event.TestDataFoo), "Other keys are still calculated")
+}
+
+func TestCalculateKeyValuesUnknownEventType(t *testing.T) {
+	events := []event.Event{{EventType: event.Invalid, Key: event.TestDataKey}}
+
+	_, err := calculateKeyValuesFromEvents(events, time.Now(), false)
+
+	if assert.Error(t, err) {
+		assert.IsType(t, &UnknownEventType{}, err)
+	}
+}
+
+func TestCalculateKeyValuesExpiry(t *testing.T) {
+	currentTime := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	past := time.Date(2022, 7, 1, 11, 0, 0, 0, time.UTC)
+	future := time.Date(2022, 7, 1, 13, 0, 0, 0, time.UTC)
+
+	expiredKeyValue := event.TestDataSetKeyValue
+	expiredKeyValue.ExpiresAt = &past
+	expiringFooBar := event.TestDataSetFooBar
+	expiringFooBar.ExpiresAt = &future
+
+	testCases := []struct {
+		name           string
+		events         []event.Event
+		includeExpired bool
+		wanted         map[string]Value
+	}{
+		{
+			name:   "Expired value is treated as unset",
+			events: []event.Event{expiringFooBar, expiredKeyValue},
+			wanted: map[string]Value{
+				event.TestDataFoo: Value(event.TestDataBar),
+			},
+		},
+		{
+			name:           "Expired value is included on request",
+			events:         []event.Event{expiringFooBar, expiredKeyValue},
+			includeExpired: true,
+			wanted: map[string]Value{
+				event.TestDataFoo: Value(event.TestDataBar),
+				event.TestDataKey: Value(event.TestDataValue),
+			},
+		},
+		{
+			name:   "Expired value doesn't reveal older value",
+			events: []event.Event{expiredKeyValue, event.TestDataSetKeyOtherValue},
+			wanted: map[string]Value{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := calculateKeyValuesFromEvents(tc.events, currentTime, tc.includeExpired)
+
+			assert.NoError(t, err)
+			assert.Truef(t, reflect.DeepEqual(got.values, tc.wanted), "Got %v, wanted %v", got.values, tc.wanted)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package ginokeva
+
+import (
+	"time"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// KeyValue represents a single key/value pair to be set
+type KeyValue struct {
+	Key       string
+	Value     string
+	ValueType ValueType
+	ExpiresAt *time.Time
+}
+
+// Precondition represents a condition on the current value of a key, which must hold for the key to be set
+type Precondition struct {
+	Absent   bool    // The key must not be set
+	Expected *string // The key must be set to this value
+}
+
+func (p Precondition) isEmpty() bool {
+	return !p.Absent && p.Expected == nil
+}
+
+func (p Precondition) check(values *Values, key string) error {
+	if err := values.Err(key); err != nil {
+		return err
+	}
+
+	var actual *string
+	if values.HasKey(key) {
+		value := string(values.Get(key))
+		actual = &value
+	}
+
+	if p.Absent && actual != nil {
+		return &PreconditionFailed{Key: key, Actual: actual}
+	}
+
+	if p.Expected != nil && (actual == nil || *actual != *p.Expected) {
+		return &PreconditionFailed{Key: key, Expected: p.Expected, Actual: actual}
+	}
+
+	return nil
+}
+
+// pendingPrecondition is the precondition of a conditional set which wasn't pushed yet. When notes of a remote are
+// merged in the meanwhile, it must still hold for these notes
+type pendingPrecondition struct {
+	rev          string
+	keys         []string
+	precondition Precondition
+}
+
+// checkPreconditions checks the pending preconditions against the notes of the notes ref, so a conditional set isn't
+// merged into notes for which it no longer holds. Returns PreconditionFailed otherwise
+func (s *Store) checkPreconditions(notesRef string) error {
+	if len(s.preconditions) == 0 {
+		return nil
+	}
+
+	other := *s
+	other.options.NotesRef = notesRef
+	for _, p := range s.preconditions {
+		values, err := other.calculateKeyValues(p.rev)
+		if err != nil {
+			return err
+		}
+
+		for _, k := range p.keys {
+			err = p.precondition.check(values, k)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Set sets the string value of the key
+func (s *Store) Set(key string, value string) error {
+	return s.SetMultiple([]KeyValue{{Key: key, Value: value}})
+}
+
+// SetMultiple sets all keys in a single note update
+func (s *Store) SetMultiple(keyValues []KeyValue) error {
+	return s.SetIf(keyValues, Precondition{}, nil)
+}
+
+// SetIf only sets the keys if the precondition holds for each of them, and returns PreconditionFailed otherwise.
+// The metadata (if any) is recorded on each of the set events. After UpstreamChanged, fetch and call SetIf again to
+// check the precondition against the updated values. Until pushed, the precondition is checked again whenever notes
+// of a remote are merged
+func (s *Store) SetIf(keyValues []KeyValue, precondition Precondition, metadata *Metadata) error {
+	if !precondition.isEmpty() {
+		values, err := s.calculateKeyValues(s.rev)
+		if err != nil {
+			return err
+		}
+
+		for _, kv := range keyValues {
+			err = precondition.check(values, kv.Key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	setEvents := []event.Event{}
+	for _, kv := range keyValues {
+		setEvent, err := event.NewTypedSetEvent(kv.Key, kv.Value, kv.ValueType)
+		if err != nil {
+			return err
+		}
+		setEvent.ExpiresAt = kv.ExpiresAt
+		setEvent.Metadata = metadata
+		setEvents = append(setEvents, *setEvent)
+	}
+
+	commitHash, err := s.addEvents(s.rev, setEvents)
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range keyValues {
+		log.WithFields(log.Fields{
+			"hash":  commitHash,
+			"key":   kv.Key,
+			"value": kv.Value,
+		}).Debug("Set event added successfully")
+	}
+
+	if !precondition.isEmpty() {
+		keys := make([]string, len(keyValues))
+		for i, kv := range keyValues {
+			keys[i] = kv.Key
+		}
+		s.preconditions = append(s.preconditions, pendingPrecondition{rev: s.rev, keys: keys, precondition: precondition})
+	}
+
+	return nil
+}
+
+// Unset unsets all keys in a single note update
+func (s *Store) Unset(keys ...string) error {
+	return s.UnsetWithMetadata(keys, nil)
+}
+
+// UnsetWithMetadata unsets all keys in a single note update, recording the metadata (if any) on each of the unset
+// events
+func (s *Store) UnsetWithMetadata(keys []string, metadata *Metadata) error {
+	unsetEvents := []event.Event{}
+	for _, key := range keys {
+		unsetEvent, err := event.NewUnsetEvent(key)
+		if err != nil {
+			return err
+		}
+		unsetEvent.Metadata = metadata
+		unsetEvents = append(unsetEvents, *unsetEvent)
+	}
+
+	commitHash, err := s.addEvents(s.rev, unsetEvents)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		log.WithFields(log.Fields{
+			"hash": commitHash,
+			"key":  key,
+		}).Debug("Unset event added successfully")
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package ginokeva
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetInvalidKey(t *testing.T) {
+	store := NewStore(newTestRepository(t), Options{})
+
+	t.Run("Key cannot be empty", func(t *testing.T) {
+		err := store.Set("", "value")
+		if assert.Error(t, err) {
+			assert.IsType(t, &event.InvalidKey{}, err)
+		}
+	})
+}
+
+func TestSetWithoutHeadEvents(t *testing.T) {
+	repo := newTestRepository(t, []event.Event{event.TestDataSetKeyValue})
+	repo.Commit("Without note")
+
+	t.Run("Set without prior events on HEAD commit doesn't fail", func(t *testing.T) {
+		err := NewStore(repo, Options{}).Set(event.TestDataFoo, event.TestDataBar)
+		assert.NoError(t, err)
+	})
+}
+
+func TestUnsetInvalidKey(t *testing.T) {
+	store := NewStore(newTestRepository(t), Options{})
+
+	t.Run("Key cannot be empty", func(t *testing.T) {
+		err := store.Unset("")
+		if assert.Error(t, err) {
+			assert.IsType(t, &event.InvalidKey{}, err)
+		}
+	})
+}
+
+func TestNamespaceConflicts(t *testing.T) {
+	newStoreWithNamespace := func() *Store {
+		store := NewStore(newTestRepository(t), Options{})
+		assert.NoError(t, store.Set("svc/foo/version", "1.0"))
+		return store
+	}
+
+	testCases := []struct {
+		name    string
+		write   func(s *Store) error
+		wantErr bool
+	}{
+		{
+			name:    "Set a namespace as value",
+			write:   func(s *Store) error { return s.Set("svc", "3") },
+			wantErr: true,
+		},
+		{
+			name:    "Set a value as namespace",
+			write:   func(s *Store) error { return s.Set("svc/foo/version/major", "1") },
+			wantErr: true,
+		},
+		{
+			name: "Increment a namespace",
+			write: func(s *Store) error {
+				_, err := s.Incr("svc/foo", 1)
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Import a namespace as value",
+			write:   func(s *Store) error { return s.Import([]KeyValue{{Key: "svc/foo", Value: "bar"}}, false, false) },
+			wantErr: true,
+		},
+		{
+			name: "Set a namespace as value after unsetting its keys",
+			write: func(s *Store) error {
+				return s.Apply([]Operation{{Unset: true, Key: "svc/foo/version"}, {Key: "svc/foo", Value: "bar"}})
+			},
+		},
+		{
+			name:  "Set another key in the namespace",
+			write: func(s *Store) error { return s.Set("svc/bar", "baz") },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newStoreWithNamespace()
+
+			err := tc.write(store)
+
+			if tc.wantErr {
+				assert.IsType(t, &NamespaceConflict{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			values, err := store.List()
+			assert.NoError(t, err)
+			_, err = values.Tree()
+			assert.NoError(t, err, "JSON output keeps working")
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package ginokeva stores key/values in git notes, so they can be read and modified from Go without executing the
+// gino-keva binary. Values are calculated for a commit by replaying the events in the notes of its history, so each
+// commit has its own snapshot of key/values.
+//
+// A Store holds the git wrapper and options to operate with. It operates on HEAD, unless another revision is
+// selected using At. Fetch and Push synchronize the notes with the remote; they're never done implicitly.
+package ginokeva
+
+import (
+	"time"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/internal/git"
+)
+
+const (
+	// DefaultNotesRef is the notes reference used if none is provided
+	DefaultNotesRef = "gino_keva"
+	// DefaultRemote is the remote used if none are provided
+	DefaultRemote = "origin"
+	// DefaultRev is the revision operated on if none is selected
+	DefaultRev = "HEAD"
+)
+
+// GitWrapper is the interface to git used by the store. Each method returns the output of the git command it stands
+// in for, and on failure an error along with the error output git would have given
+type GitWrapper interface {
+	CommitInfo(hash string) (string, error)
+	ConfigGet(key string) (string, error)
+	DeleteNotesRef(notesRef string) (string, error)
+	FetchNotes(remote string, notesRef string, force bool) (string, error)
+	FetchNotesInto(remote string, notesRef string, localNotesRef string) (string, error)
+	LogCommitsEach(rev string, exclude []string, fn func(hash string) bool) (string, error)
+	NotesAdd(notesRef, hash, msg string) (string, error)
+	NotesList(notesRef string) (string, error)
+	NotesMerge(notesRef, otherNotesRef string) (string, error)
+	NotesPrune(notesRef string) (string, error)
+	NotesShow(notesRef, hash string) (string, error)
+	PushNotes(remote string, notesRef string) (string, error)
+	ReadBlobs(fn func(readBlob func(hash string) (string, error)) error) error
+	RevParse(rev string) (string, error)
+	UpdateNotesRef(notesRef, hash string) (string, error)
+}
+
+// NewCLIGitWrapper returns a GitWrapper executing the git binary in the working directory
+func NewCLIGitWrapper() GitWrapper {
+	return &git.GoGitCmdWrapper{}
+}
+
+// NewNativeGitWrapper returns a GitWrapper using a built-in git implementation on the repository in the working
+// directory, so the git binary isn't needed
+func NewNativeGitWrapper() GitWrapper {
+	return &git.GoGitNativeWrapper{}
+}
+
+// Metadata holds optional information on the origin of a change, which is recorded along with it
+type Metadata = event.Metadata
+
+// ValueType represents the type of a value
+type ValueType = event.ValueType
+
+// Supported value types
+const (
+	String = event.String
+	Int    = event.Int
+	Bool   = event.Bool
+	JSON   = event.JSON
+	List   = event.List
+)
+
+// Options configures a Store. The zero value of each option selects its default
+type Options struct {
+	NotesRef           string           // Name of the notes reference (default gino_keva)
+	Remotes            []string         // Names of the remotes to fetch from and push to, in order (default origin)
+	CheckpointInterval int              // Write a checkpoint when this many notes were written since the last one (0 to disable)
+	Now                func() time.Time // Returns the time against which expiry of values is evaluated (default time.Now)
+}
+
+// Store reads and modifies the key/values stored in git notes
+type Store struct {
+	git     GitWrapper
+	options Options
+	rev     string
+
+	preconditions []pendingPrecondition // Preconditions of the conditional sets which weren't pushed yet
+}
+
+// NewStore returns a store operating on HEAD
+func NewStore(gitWrapper GitWrapper, options Options) *Store {
+	if options.NotesRef == "" {
+		options.NotesRef = DefaultNotesRef
+	}
+	if len(options.Remotes) == 0 {
+		options.Remotes = []string{DefaultRemote}
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	return &Store{
+		git:     gitWrapper,
+		options: options,
+		rev:     DefaultRev,
+	}
+}
+
+// At returns a copy of the store operating on the commit-ish rev (hash, branch, tag, ...)
+func (s *Store) At(rev string) *Store {
+	c := *s
+	c.rev = rev
+	return &c
+}
+
+// NotesRef returns the name of the notes reference
+func (s *Store) NotesRef() string {
+	return s.options.NotesRef
+}
+
+// Remotes returns the names of the remotes to fetch from and push to
+func (s *Store) Remotes() []string {
+	return s.options.Remotes
+}
+
+// Rev returns the commit-ish the store operates on
+func (s *Store) Rev() string {
+	return s.rev
+}
+
+// now returns the current time, against which expiry of values is evaluated
+func (s *Store) now() time.Time {
+	return s.options.Now()
+}
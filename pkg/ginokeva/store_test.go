@@ -0,0 +1,125 @@
+package ginokeva
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/gitfake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("Key/values set by one clone are available in another after push and fetch", func(t *testing.T) {
+		remote := gitfake.NewRemote()
+		alice := remote.Clone()
+		alice.Commit("First")
+		assert.NoError(t, alice.PushBranch(gitfake.DefaultBranch))
+
+		store := NewStore(alice, Options{})
+		assert.NoError(t, store.Set("foo", "bar"))
+		_, err := store.Incr("counter", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, store.Push())
+
+		bob := remote.Clone()
+		other := NewStore(bob, Options{})
+		assert.NoError(t, other.Fetch())
+
+		value, err := other.Get("foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", value)
+
+		values, err := other.List()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]Value{"foo": "bar", "counter": "2"}, values.Iterate())
+	})
+
+	t.Run("Unset key is no longer found", func(t *testing.T) {
+		repo := gitfake.NewRepository()
+		repo.Commit("First")
+
+		store := NewStore(repo, Options{})
+		assert.NoError(t, store.Set("foo", "bar"))
+		assert.NoError(t, store.Unset("foo"))
+
+		_, err := store.Get("foo")
+		assert.IsType(t, &KeyNotFound{}, err)
+	})
+
+	t.Run("Older revisions don't see newer changes", func(t *testing.T) {
+		repo := gitfake.NewRepository()
+		first := repo.Commit("First")
+
+		store := NewStore(repo, Options{})
+		assert.NoError(t, store.Set("foo", "bar"))
+		repo.Commit("Second")
+		assert.NoError(t, store.Set("foo", "baz"))
+
+		value, err := store.At(first).Get("foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", value)
+
+		history, err := store.History("foo")
+		assert.NoError(t, err)
+		if assert.Len(t, history, 2) {
+			assert.Equal(t, event.Set, history[0].EventType)
+			assert.Equal(t, "baz", *history[0].Value)
+			assert.Equal(t, first, history[1].Hash)
+		}
+	})
+
+	t.Run("Notes are stored under the configured notes ref", func(t *testing.T) {
+		repo := gitfake.NewRepository()
+		repo.Commit("First")
+
+		store := NewStore(repo, Options{NotesRef: "custom"})
+		assert.NoError(t, store.Set("foo", "bar"))
+
+		assert.Len(t, repo.Notes("custom"), 1)
+		assert.Empty(t, repo.Notes(DefaultNotesRef))
+	})
+
+	t.Run("Notes are pushed to and fetched from each remote", func(t *testing.T) {
+		remote := gitfake.NewRemote()
+		alice := remote.Clone()
+		alice.Commit("First")
+		assert.NoError(t, alice.PushBranch(gitfake.DefaultBranch))
+		mirror := remote.Mirror()
+		assert.NoError(t, alice.AddRemote("mirror", mirror))
+
+		store := NewStore(alice, Options{Remotes: []string{"origin", "mirror"}})
+		assert.NoError(t, store.Set("foo", "bar"))
+		assert.NoError(t, store.Push())
+		assert.Equal(t, alice.Notes(DefaultNotesRef), remote.Notes(DefaultNotesRef))
+		assert.Equal(t, alice.Notes(DefaultNotesRef), mirror.Notes(DefaultNotesRef))
+
+		bob := remote.Clone()
+		assert.NoError(t, bob.AddRemote("mirror", mirror))
+		other := NewStore(bob, Options{Remotes: []string{"mirror", "origin"}})
+		assert.NoError(t, other.Fetch())
+
+		value, err := other.Get("foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", value)
+	})
+
+	t.Run("Failures are reported per remote", func(t *testing.T) {
+		remote := gitfake.NewRemote()
+		repo := remote.Clone()
+		repo.Commit("First")
+
+		store := NewStore(repo, Options{Remotes: []string{"origin", "unknown"}})
+		assert.NoError(t, store.Fetch(), "Fetching from one of the remotes is enough")
+		assert.NoError(t, store.Set("foo", "bar"))
+
+		err := store.Push()
+		if assert.IsType(t, &PushFailed{}, err) {
+			assert.Contains(t, err.(*PushFailed).Errors, "unknown")
+			assert.NotContains(t, err.(*PushFailed).Errors, "origin")
+		}
+		assert.Equal(t, repo.Notes(DefaultNotesRef), remote.Notes(DefaultNotesRef))
+
+		err = NewStore(repo, Options{Remotes: []string{"unknown"}}).Fetch()
+		assert.IsType(t, &FetchFailed{}, err)
+	})
+}
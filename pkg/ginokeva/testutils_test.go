@@ -0,0 +1,52 @@
+package ginokeva
+
+import (
+	"testing"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/gitfake"
+	"github.com/stretchr/testify/assert"
+)
+
+// spyRepository records the notes read from the repository
+type spyRepository struct {
+	*gitfake.Repository
+	shown []string
+}
+
+// newTestRepository returns a repository with a commit for each note, annotated with the events of the note (newest
+// note in front)
+func newTestRepository(t *testing.T, notes ...[]event.Event) *spyRepository {
+	repo := &spyRepository{Repository: gitfake.NewRepository()}
+	repo.Commit("Initial commit")
+
+	for i := len(notes) - 1; i >= 0; i-- {
+		hash := repo.Commit("Commit")
+		eventsJSON, err := event.Marshal(&notes[i])
+		if assert.NoError(t, err) {
+			_, err = repo.NotesAdd(DefaultNotesRef, hash, eventsJSON)
+			assert.NoError(t, err)
+		}
+	}
+
+	return repo
+}
+
+// ReadBlobs records the commits of the notes read
+func (r *spyRepository) ReadBlobs(fn func(readBlob func(hash string) (string, error)) error) error {
+	blobs, err := getNotesBlobs(r.Repository, DefaultNotesRef)
+	if err != nil {
+		return err
+	}
+	commits := map[string]string{} // Blob hash -> commit hash
+	for commit, blob := range blobs {
+		commits[blob] = commit
+	}
+
+	return r.Repository.ReadBlobs(func(readBlob func(hash string) (string, error)) error {
+		return fn(func(hash string) (string, error) {
+			r.shown = append(r.shown, commits[hash])
+			return readBlob(hash)
+		})
+	})
+}
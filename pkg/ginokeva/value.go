@@ -0,0 +1,428 @@
+package ginokeva
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"gopkg.in/yaml.v3"
+)
+
+// SortOrder represents the order in which the keys of a collection are returned
+type SortOrder int
+
+const (
+	// SortByKey sorts the keys alphabetically
+	SortByKey SortOrder = iota
+	// SortByLastModified sorts the keys by the commit they were last modified in, most recent first
+	SortByLastModified
+)
+
+// Values represents a collection of values
+type Values struct {
+	values    map[string]Value
+	metadata  map[string]valueMetadata
+	order     []string // Order in which keys were added
+	sortOrder SortOrder
+	errors    map[string]error         // Keys whose value couldn't be calculated -> why
+	unset     map[string]valueMetadata // Keys which were unset, recorded by replay so checkpoints can keep them unset
+}
+
+type valueMetadata struct {
+	commit    string // Hash of the commit the key was last modified in, if known
+	valueType event.ValueType
+	expiresAt *time.Time      // Moment the value expires, if any
+	origin    *event.Metadata // Metadata of the event the key was last modified by, if recorded
+}
+
+// Add a key/value to the collection
+func (v *Values) Add(key string, value Value) {
+	v.add(key, value, valueMetadata{})
+}
+
+func (v *Values) add(key string, value Value, metadata valueMetadata) {
+	if _, ok := v.values[key]; !ok {
+		v.order = append(v.order, key)
+	}
+	v.values[key] = value
+	v.metadata[key] = metadata
+	delete(v.errors, key)
+	delete(v.unset, key)
+}
+
+// addErr records why the value of the key couldn't be calculated. The key is left out of the collection, so the other
+// keys can still be used
+func (v *Values) addErr(key string, err error) {
+	v.errors[key] = err
+}
+
+// Err returns the error that prevented the value of the key from being calculated, or nil if there is none. Such a
+// key is left out of the collection, so only reading or changing that key should fail
+func (v Values) Err(key string) error {
+	return v.errors[key]
+}
+
+// firstErr returns the error of the first key whose value couldn't be calculated, or nil if every value could be
+func (v Values) firstErr() error {
+	keys := []string{}
+	for k := range v.errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		return nil
+	}
+	return v.errors[keys[0]]
+}
+
+// Commit returns the hash of the commit in which the key was last modified, or an empty string if unknown
+func (v Values) Commit(key string) string {
+	return v.metadata[key].commit
+}
+
+// ExpiresAt returns the moment the value of the key expires, or nil if it doesn't expire
+func (v Values) ExpiresAt(key string) *time.Time {
+	return v.metadata[key].expiresAt
+}
+
+// Origin returns the metadata recorded on the event the key was last modified by, or nil if none was recorded
+func (v Values) Origin(key string) *Metadata {
+	return v.metadata[key].origin
+}
+
+// ValueType returns the value type of the key
+func (v Values) ValueType(key string) event.ValueType {
+	return v.metadata[key].valueType
+}
+
+// Native returns the value of the key converted to its native Go type, based on its value type. Values of type
+// JSON are returned as json.RawMessage
+func (v Values) Native(key string) (interface{}, error) {
+	value := string(v.values[key])
+
+	switch v.ValueType(key) {
+	case event.Int:
+		return strconv.ParseInt(value, 10, 64)
+	case event.Bool:
+		return strconv.ParseBool(value)
+	case event.JSON:
+		if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("invalid JSON value for key %v", key)
+		}
+		return json.RawMessage(value), nil
+	case event.List:
+		return event.ParseList(value)
+	default:
+		return value, nil
+	}
+}
+
+// Count returns number of items in collection
+func (v Values) Count() int {
+	return len(v.values)
+}
+
+// Get returns a single value from the collection
+func (v Values) Get(key string) Value {
+	return v.values[key]
+}
+
+// HasKey indicates if key exists in collection
+func (v Values) HasKey(key string) bool {
+	_, ok := v.values[key]
+	return ok
+}
+
+// Iterate the collection value data
+func (v Values) Iterate() map[string]Value {
+	return v.values
+}
+
+// Keys returns the keys of the collection in the configured sort order
+func (v Values) Keys() []string {
+	keys := make([]string, len(v.order))
+	copy(keys, v.order)
+
+	if v.sortOrder == SortByKey {
+		sort.Strings(keys)
+	}
+
+	return keys
+}
+
+// Filter returns a new collection holding only the keys for which keep returns true
+func (v Values) Filter(keep func(key string) bool) *Values {
+	filtered := NewValues()
+	filtered.sortOrder = v.sortOrder
+
+	for _, k := range v.order {
+		if keep(k) {
+			filtered.add(k, v.values[k], v.metadata[k])
+		}
+	}
+	for k, err := range v.errors {
+		if keep(k) {
+			filtered.addErr(k, err)
+		}
+	}
+
+	return filtered
+}
+
+// Namespace returns a new collection holding only the keys inside the namespace, relative to that namespace
+func (v Values) Namespace(namespace string) *Values {
+	prefix := namespace + event.NamespaceSeparator
+
+	values := NewValues()
+	values.sortOrder = v.sortOrder
+
+	for _, k := range v.order {
+		if strings.HasPrefix(k, prefix) {
+			values.add(strings.TrimPrefix(k, prefix), v.values[k], v.metadata[k])
+		}
+	}
+	for k, err := range v.errors {
+		if strings.HasPrefix(k, prefix) {
+			values.addErr(strings.TrimPrefix(k, prefix), err)
+		}
+	}
+
+	return values
+}
+
+// Tree returns a hierarchical view of the collection, with a level per namespace segment
+func (v Values) Tree() (*ValuesTree, error) {
+	tree := newValuesTree()
+
+	for _, k := range v.Keys() {
+		segments := strings.Split(k, event.NamespaceSeparator)
+
+		node := tree
+		for i, segment := range segments[:len(segments)-1] {
+			if node.isValue(segment) {
+				return nil, &NamespaceConflict{Key: strings.Join(segments[:i+1], event.NamespaceSeparator)}
+			}
+			node = node.child(segment)
+		}
+
+		name := segments[len(segments)-1]
+		if node.isChild(name) {
+			return nil, &NamespaceConflict{Key: k}
+		}
+
+		native, err := v.Native(k)
+		if err != nil {
+			return nil, err
+		}
+		node.order = append(node.order, name)
+		node.values[name] = native
+	}
+
+	return tree, nil
+}
+
+// checkNamespaceConflict returns NamespaceConflict if the key can't be added to the collection, since the key or one
+// of its namespaces is already used the other way around. Keys whose value couldn't be calculated still count
+func (v Values) checkNamespaceConflict(key string) error {
+	used := func(k string) bool {
+		_, hasErr := v.errors[k]
+		return v.HasKey(k) || hasErr
+	}
+
+	segments := strings.Split(key, event.NamespaceSeparator)
+	for i := 1; i < len(segments); i++ {
+		if namespace := strings.Join(segments[:i], event.NamespaceSeparator); used(namespace) {
+			return &NamespaceConflict{Key: namespace}
+		}
+	}
+
+	prefix := key + event.NamespaceSeparator
+	for k := range v.values {
+		if strings.HasPrefix(k, prefix) {
+			return &NamespaceConflict{Key: key}
+		}
+	}
+	for k := range v.errors {
+		if strings.HasPrefix(k, prefix) {
+			return &NamespaceConflict{Key: key}
+		}
+	}
+
+	return nil
+}
+
+// Remove a key from the collection
+func (v *Values) Remove(key string) {
+	delete(v.errors, key)
+	if _, ok := v.values[key]; !ok {
+		return
+	}
+	delete(v.values, key)
+	delete(v.metadata, key)
+
+	for i, k := range v.order {
+		if k == key {
+			v.order = append(v.order[:i], v.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// SetSortOrder sets the order in which the keys of the collection are returned. Keys are added in the order
+// of replay (newest first), so the insertion order corresponds to sorting by last modification.
+func (v *Values) SetSortOrder(sortOrder SortOrder) {
+	v.sortOrder = sortOrder
+}
+
+// MarshalJSON marshals the collection as a JSON object, respecting the sort order
+func (v Values) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	for i, k := range v.Keys() {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		native, err := v.Native(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(native)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.Write(key)
+		buffer.WriteString(":")
+		buffer.Write(value)
+	}
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+// MarshalYAML marshals the collection as a YAML mapping, respecting the sort order
+func (v Values) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range v.Keys() {
+		native, err := v.Native(k)
+		if err != nil {
+			return nil, err
+		}
+
+		if raw, ok := native.(json.RawMessage); ok {
+			if err := json.Unmarshal(raw, &native); err != nil {
+				return nil, err
+			}
+		}
+
+		value := &yaml.Node{}
+		if err := value.Encode(native); err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, value)
+	}
+
+	return node, nil
+}
+
+// NewValues returns a new values map
+func NewValues() *Values {
+	return &Values{
+		values:   make(map[string]Value),
+		metadata: make(map[string]valueMetadata),
+		order:    []string{},
+		errors:   make(map[string]error),
+		unset:    make(map[string]valueMetadata),
+	}
+}
+
+// NamespaceConflict error indicates a key is used both as a value and as a namespace
+type NamespaceConflict struct {
+	Key string
+}
+
+func (n NamespaceConflict) Error() string {
+	return fmt.Sprintf("Key %v is used both as a value and as a namespace", n.Key)
+}
+
+// ValuesTree represents a hierarchical view of a collection of values
+type ValuesTree struct {
+	order    []string               // Order in which values and children were added
+	values   map[string]interface{} // Native values
+	children map[string]*ValuesTree
+}
+
+func newValuesTree() *ValuesTree {
+	return &ValuesTree{
+		order:    []string{},
+		values:   make(map[string]interface{}),
+		children: make(map[string]*ValuesTree),
+	}
+}
+
+func (t *ValuesTree) child(name string) *ValuesTree {
+	if c, ok := t.children[name]; ok {
+		return c
+	}
+
+	c := newValuesTree()
+	t.order = append(t.order, name)
+	t.children[name] = c
+	return c
+}
+
+func (t ValuesTree) isChild(name string) bool {
+	_, ok := t.children[name]
+	return ok
+}
+
+func (t ValuesTree) isValue(name string) bool {
+	_, ok := t.values[name]
+	return ok
+}
+
+// MarshalJSON marshals the tree as nested JSON objects, respecting the order in which items were added
+func (t ValuesTree) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	for i, name := range t.order {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var item []byte
+		if c, ok := t.children[name]; ok {
+			item, err = json.Marshal(c)
+		} else {
+			item, err = json.Marshal(t.values[name])
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.Write(key)
+		buffer.WriteString(":")
+		buffer.Write(item)
+	}
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+// Value represents the parsed value as stored in git notes
+type Value string
@@ -0,0 +1,748 @@
+// Package gitfake provides an in-memory implementation of the git wrapper used by gino-keva. It models commits,
+// branches, notes refs and a remote, so realistic scenarios can be tested without a real repository.
+//
+// Like the git CLI, each method returns the output of the git command it stands in for, and on failure an error
+// along with the error output git would have given. This way rejected pushes and fetches are reported exactly like
+// the git CLI does.
+package gitfake
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBranch is the branch checked out in a new repository
+	DefaultBranch = "main"
+
+	notesRefPrefix  = "refs/notes/"
+	headsRefPrefix  = "refs/heads/"
+	remoteName      = "origin"
+	minPrefixLength = 4
+)
+
+// epoch is the date of the first commit. Each next commit is one minute later, so dates are predictable
+var epoch = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// errExit is returned along with the output of a failed command, like exec does for the git CLI
+var errExit = errors.New("exit status 1")
+
+type commit struct {
+	hash    string
+	parents []string
+	message string
+	date    time.Time
+
+	notes map[string]string // Only for commits of a notes ref: commit hash -> note
+}
+
+// objectStore holds the commits of a remote and all of its clones
+type objectStore struct {
+	mu      sync.Mutex
+	commits map[string]*commit
+	blobs   map[string]string // Blob hash -> contents
+	count   int
+}
+
+func newObjectStore() *objectStore {
+	return &objectStore{commits: map[string]*commit{}, blobs: map[string]string{}}
+}
+
+func (s *objectStore) add(parents []string, message string, notes map[string]string) *commit {
+	c := &commit{
+		parents: parents,
+		message: message,
+		date:    epoch.Add(time.Duration(s.count) * time.Minute),
+		notes:   notes,
+	}
+	s.count++
+	c.hash = hash(fmt.Sprintf("commit %d\n%v\n%v", s.count, strings.Join(parents, " "), message))
+	s.commits[c.hash] = c
+	return c
+}
+
+// addBlob stores the contents as blob, so it can be read by its hash
+func (s *objectStore) addBlob(contents string) {
+	s.blobs[hash(contents)] = contents
+}
+
+// isAncestor returns whether commit a is an ancestor of (or equal to) commit b
+func (s *objectStore) isAncestor(a, b string) bool {
+	found := false
+	s.walk(b, func(c *commit) bool {
+		found = c.hash == a
+		return !found
+	})
+	return found
+}
+
+// mergeBase returns a common ancestor of commits a and b, if any
+func (s *objectStore) mergeBase(a, b string) (string, bool) {
+	ancestors := map[string]bool{}
+	s.walk(a, func(c *commit) bool {
+		ancestors[c.hash] = true
+		return true
+	})
+
+	base := ""
+	s.walk(b, func(c *commit) bool {
+		if ancestors[c.hash] {
+			base = c.hash
+		}
+		return base == ""
+	})
+	return base, base != ""
+}
+
+// walk calls fn for each commit reachable from hash, newest first, until fn returns false
+func (s *objectStore) walk(hash string, fn func(c *commit) bool) {
+	seen := map[string]bool{hash: true}
+	queue := []*commit{s.commits[hash]}
+
+	for len(queue) > 0 {
+		// Like git log, continue with the most recent commit of those not yet shown
+		sort.Slice(queue, func(i, j int) bool { return queue[i].date.After(queue[j].date) })
+		c := queue[0]
+		queue = queue[1:]
+
+		if !fn(c) {
+			return
+		}
+
+		for _, p := range c.parents {
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, s.commits[p])
+			}
+		}
+	}
+}
+
+// hash returns the hash of a git blob with the provided contents
+func hash(contents string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(contents), contents))))
+}
+
+// refs holds the branches and notes refs of a repository
+type refs struct {
+	branches map[string]string // Branch name -> commit hash
+	notes    map[string]string // Notes ref -> commit hash
+}
+
+func newRefs() refs {
+	return refs{branches: map[string]string{}, notes: map[string]string{}}
+}
+
+func (r refs) copy() refs {
+	c := newRefs()
+	for k, v := range r.branches {
+		c.branches[k] = v
+	}
+	for k, v := range r.notes {
+		c.notes[k] = v
+	}
+	return c
+}
+
+// Remote is an in-memory remote repository. Its clones share all commits, but each clone has its own refs
+type Remote struct {
+	store *objectStore
+	refs  refs
+}
+
+// NewRemote returns an empty remote
+func NewRemote() *Remote {
+	return &Remote{store: newObjectStore(), refs: newRefs()}
+}
+
+// Clone returns a new repository with the branches and notes of the remote, and the remote configured as origin
+func (r *Remote) Clone() *Repository {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return &Repository{
+		store:   r.store,
+		remotes: map[string]*Remote{remoteName: r},
+		refs:    r.refs.copy(),
+		head:    DefaultBranch,
+		config:  map[string]string{},
+	}
+}
+
+// Mirror returns a new remote with the same commits, branches and notes, like a mirrored repository. Add it to a
+// clone using AddRemote
+func (r *Remote) Mirror() *Remote {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return &Remote{store: r.store, refs: r.refs.copy()}
+}
+
+// Notes returns the notes of the notes ref on the remote (commit hash -> note)
+func (r *Remote) Notes(notesRef string) map[string]string {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return copyNotes(r.store.notes(r.refs.notes[notesRef]))
+}
+
+// Repository is an in-memory repository implementing the git wrapper used by gino-keva. It's safe for concurrent
+// use, also together with other clones of the same remote
+type Repository struct {
+	store   *objectStore
+	remotes map[string]*Remote // Remote name -> remote
+	refs    refs
+	head    string // Name of the checked out branch, or hash of the checked out commit (detached)
+	config  map[string]string
+}
+
+// NewRepository returns an empty repository without remote
+func NewRepository() *Repository {
+	return &Repository{
+		store:   newObjectStore(),
+		remotes: map[string]*Remote{},
+		refs:    newRefs(),
+		head:    DefaultBranch,
+		config:  map[string]string{},
+	}
+}
+
+// AddRemote adds the remote under the provided name. The remote must share its commits with the repository, so it
+// must be a mirror of (a remote of) the repository
+func (r *Repository) AddRemote(name string, remote *Remote) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if remote.store != r.store {
+		return fmt.Errorf("fatal: remote %v doesn't share its commits with the repository", name)
+	}
+	r.remotes[name] = remote
+	return nil
+}
+
+// Commit adds a commit on top of the checked out commit, and returns its hash
+func (r *Repository) Commit(message string) string {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	parents := []string{}
+	if h, ok := r.headHash(); ok {
+		parents = append(parents, h)
+	}
+	return r.advanceHead(r.store.add(parents, message, nil).hash)
+}
+
+// Merge adds a merge commit of the provided rev into the checked out commit, and returns its hash
+func (r *Repository) Merge(rev string, message string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	other, err := r.resolve(rev)
+	if err != nil {
+		return "", err
+	}
+	h, ok := r.headHash()
+	if !ok {
+		return "", fmt.Errorf("fatal: cannot merge into empty branch %v", r.head)
+	}
+
+	return r.advanceHead(r.store.add([]string{h, other}, message, nil).hash), nil
+}
+
+// Branch creates a branch at the checked out commit
+func (r *Repository) Branch(name string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, ok := r.headHash()
+	if !ok {
+		return fmt.Errorf("fatal: not a valid object name: '%v'", r.head)
+	}
+	r.refs.branches[name] = h
+	return nil
+}
+
+// Checkout checks out a branch, or any other rev (detached)
+func (r *Repository) Checkout(rev string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.refs.branches[rev]; ok {
+		r.head = rev
+		return nil
+	}
+
+	h, err := r.resolve(rev)
+	if err != nil {
+		return err
+	}
+	r.head = h
+	return nil
+}
+
+// PushBranch updates the branch on origin to the local branch
+func (r *Repository) PushBranch(name string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	remote, ok := r.remotes[remoteName]
+	if !ok {
+		return fmt.Errorf("fatal: '%v' does not appear to be a git repository", remoteName)
+	}
+	h, ok := r.refs.branches[name]
+	if !ok {
+		return fmt.Errorf("error: src refspec %v does not match any", name)
+	}
+	remote.refs.branches[name] = h
+	return nil
+}
+
+// SetConfig sets the value of a git config key
+func (r *Repository) SetConfig(key, value string) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.config[key] = value
+}
+
+// Notes returns the notes of the notes ref (commit hash -> note)
+func (r *Repository) Notes(notesRef string) map[string]string {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return copyNotes(r.store.notes(r.refs.notes[notesRef]))
+}
+
+// CommitInfo returns hash, author date and subject of the commit separated by tabs
+func (r *Repository) CommitInfo(hash string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, err := r.resolve(hash)
+	if err != nil {
+		return err.Error(), errExit
+	}
+
+	c := r.store.commits[h]
+	subject := strings.Join(strings.Fields(strings.SplitN(c.message, "\n\n", 2)[0]), " ")
+	return fmt.Sprintf("%v\t%v\t%v", c.hash, c.date.Format("2006-01-02T15:04:05-07:00"), subject), nil
+}
+
+// ConfigGet returns the value of the git config key, or error if it's not set
+func (r *Repository) ConfigGet(key string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	value, ok := r.config[key]
+	if !ok {
+		return "", errExit
+	}
+	return value + "\n", nil
+}
+
+// DeleteNotesRef deletes the notes ref
+func (r *Repository) DeleteNotesRef(notesRef string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.refs.notes, notesRef)
+	return "", nil
+}
+
+// FetchNotes fetches the notes ref from the remote. Without force, only fast-forward updates are accepted
+func (r *Repository) FetchNotes(remote string, notesRef string, force bool) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	ref := notesRefPrefix + notesRef
+	rem, ok := r.remotes[remote]
+	if !ok {
+		return fmt.Sprintf("fatal: '%v' does not appear to be a git repository\n", remote), errExit
+	}
+
+	remoteTip, ok := rem.refs.notes[notesRef]
+	if !ok {
+		return fmt.Sprintf("fatal: couldn't find remote ref %v\n", ref), errExit
+	}
+
+	localTip, ok := r.refs.notes[notesRef]
+	if ok && !force && !r.store.isAncestor(localTip, remoteTip) {
+		return fmt.Sprintf(" ! [rejected]        %v -> %v  (non-fast-forward)\n", ref, ref), errExit
+	}
+
+	r.refs.notes[notesRef] = remoteTip
+	return "", nil
+}
+
+// FetchNotesInto force-fetches the notes ref from the remote into another local notes ref
+func (r *Repository) FetchNotesInto(remote string, notesRef string, localNotesRef string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	rem, ok := r.remotes[remote]
+	if !ok {
+		return fmt.Sprintf("fatal: '%v' does not appear to be a git repository\n", remote), errExit
+	}
+
+	remoteTip, ok := rem.refs.notes[notesRef]
+	if !ok {
+		return fmt.Sprintf("fatal: couldn't find remote ref %v\n", notesRefPrefix+notesRef), errExit
+	}
+
+	r.refs.notes[localNotesRef] = remoteTip
+	return "", nil
+}
+
+// LogCommitsEach streams the hashes of the commits reachable from rev, but not from any of the excluded commits, to
+// fn, newest first, until fn returns false
+func (r *Repository) LogCommitsEach(rev string, exclude []string, fn func(hash string) bool) (string, error) {
+	r.store.mu.Lock()
+	h, err := r.resolve(rev)
+	if err != nil {
+		r.store.mu.Unlock()
+		return err.Error(), errExit
+	}
+
+	excluded := map[string]bool{}
+	for _, e := range exclude {
+		eh, err := r.resolve(e)
+		if err != nil {
+			r.store.mu.Unlock()
+			return err.Error(), errExit
+		}
+		r.store.walk(eh, func(c *commit) bool {
+			excluded[c.hash] = true
+			return true
+		})
+	}
+
+	hashes := []string{}
+	r.store.walk(h, func(c *commit) bool {
+		if !excluded[c.hash] {
+			hashes = append(hashes, c.hash)
+		}
+		return true
+	})
+	r.store.mu.Unlock()
+
+	// Call fn without holding the lock, so it may use the repository
+	for _, hash := range hashes {
+		if !fn(hash) {
+			break
+		}
+	}
+	return "", nil
+}
+
+// NotesAdd sets/overwrites the note on the provided hash
+func (r *Repository) NotesAdd(notesRef, hash, msg string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, err := r.resolve(hash)
+	if err != nil {
+		return err.Error(), errExit
+	}
+
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	r.store.addBlob(msg)
+	r.updateNotes(notesRef, "Notes added by 'git notes add'", func(notes map[string]string) {
+		notes[h] = msg
+	})
+	return "", nil
+}
+
+// NotesList returns all the notes, as blob hash and commit hash per line
+func (r *Repository) NotesList(notesRef string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	notes := r.store.notes(r.refs.notes[notesRef])
+	commits := make([]string, 0, len(notes))
+	for c := range notes {
+		commits = append(commits, c)
+	}
+	sort.Strings(commits)
+
+	var sb strings.Builder
+	for _, c := range commits {
+		fmt.Fprintf(&sb, "%v %v\n", hash(notes[c]), c)
+	}
+	return sb.String(), nil
+}
+
+// NotesMerge merges the other notes ref into the notes ref. Like git notes merge with the ours strategy, notes changed
+// on both sides since the merge base are resolved in favor of the notes ref
+func (r *Repository) NotesMerge(notesRef, otherNotesRef string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	other, ok := r.refs.notes[otherNotesRef]
+	if !ok {
+		return fmt.Sprintf("fatal: failed to resolve '%v' as a valid ref.\n", notesRefPrefix+otherNotesRef), errExit
+	}
+
+	local, ok := r.refs.notes[notesRef]
+	switch {
+	case ok && r.store.isAncestor(other, local):
+		return "", nil // Already up to date
+	case !ok || r.store.isAncestor(local, other):
+		r.refs.notes[notesRef] = other // Fast-forward
+		return "", nil
+	}
+
+	base := map[string]string{}
+	if b, ok := r.store.mergeBase(local, other); ok {
+		base = r.store.notes(b)
+	}
+	ours, theirs := r.store.notes(local), r.store.notes(other)
+
+	notes := copyNotes(ours)
+	for c, note := range base {
+		if _, ok := theirs[c]; !ok && ours[c] == note {
+			delete(notes, c) // Removed by them only
+		}
+	}
+	for c, note := range theirs {
+		baseNote, inBase := base[c]
+		ourNote, inOurs := ours[c]
+		if (!inBase && !inOurs) || (inBase && inOurs && ourNote == baseNote) {
+			notes[c] = note // Added or changed by them only
+		}
+	}
+
+	message := fmt.Sprintf("Merged notes from %v into %v", notesRefPrefix+otherNotesRef, notesRefPrefix+notesRef)
+	r.refs.notes[notesRef] = r.store.add([]string{local, other}, message, notes).hash
+	return "", nil
+}
+
+// NotesPrune removes the notes of commits that don't exist
+func (r *Repository) NotesPrune(notesRef string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	unknown := []string{}
+	for c := range r.store.notes(r.refs.notes[notesRef]) {
+		if _, ok := r.store.commits[c]; !ok {
+			unknown = append(unknown, c)
+		}
+	}
+
+	if len(unknown) > 0 {
+		r.updateNotes(notesRef, "Notes removed by 'git notes prune'", func(notes map[string]string) {
+			for _, c := range unknown {
+				delete(notes, c)
+			}
+		})
+	}
+	return "", nil
+}
+
+// NotesShow returns the note for provided hash, or error if there is none
+func (r *Repository) NotesShow(notesRef, hash string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, err := r.resolve(hash)
+	if err != nil {
+		return err.Error(), errExit
+	}
+
+	note, ok := r.store.notes(r.refs.notes[notesRef])[h]
+	if !ok {
+		return fmt.Sprintf("error: no note found for object %v.\n", h), errExit
+	}
+	return note, nil
+}
+
+// PushNotes pushes the notes ref to the remote. Only fast-forward updates are accepted
+func (r *Repository) PushNotes(remote string, notesRef string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	ref := notesRefPrefix + notesRef
+	rem, ok := r.remotes[remote]
+	if !ok {
+		return fmt.Sprintf("fatal: '%v' does not appear to be a git repository\n", remote), errExit
+	}
+
+	localTip, ok := r.refs.notes[notesRef]
+	if !ok {
+		return fmt.Sprintf("error: src refspec %v does not match any\n", ref), errExit
+	}
+
+	remoteTip, ok := rem.refs.notes[notesRef]
+	if ok && !r.store.isAncestor(remoteTip, localTip) {
+		return fmt.Sprintf(" ! [rejected]        %v -> %v (fetch first)\n", ref, ref), errExit
+	}
+
+	rem.refs.notes[notesRef] = localTip
+	return "", nil
+}
+
+// ReadBlobs calls fn with a function returning the contents of a blob
+func (r *Repository) ReadBlobs(fn func(readBlob func(hash string) (string, error)) error) error {
+	return fn(func(hash string) (string, error) {
+		r.store.mu.Lock()
+		defer r.store.mu.Unlock()
+
+		contents, ok := r.store.blobs[hash]
+		if !ok {
+			return "", fmt.Errorf("cannot read blob %v: %v missing", hash, hash)
+		}
+		return contents, nil
+	})
+}
+
+// RevParse returns the commit hash the provided rev points to
+func (r *Repository) RevParse(rev string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, err := r.resolve(rev)
+	if err != nil {
+		return err.Error(), errExit
+	}
+	return h + "\n", nil
+}
+
+// UpdateNotesRef points the notes ref at the notes commit
+func (r *Repository) UpdateNotesRef(notesRef, hash string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.commits[hash]; !ok {
+		return fmt.Sprintf("fatal: %v: not a valid SHA1\n", hash), errExit
+	}
+
+	r.refs.notes[notesRef] = hash
+	return "", nil
+}
+
+// headHash returns the hash of the checked out commit, if any
+func (r *Repository) headHash() (string, bool) {
+	if h, ok := r.refs.branches[r.head]; ok {
+		return h, true
+	}
+	_, ok := r.store.commits[r.head]
+	return r.head, ok
+}
+
+// advanceHead moves the checked out branch (or detached head) to the commit
+func (r *Repository) advanceHead(hash string) string {
+	if _, ok := r.store.commits[r.head]; ok {
+		r.head = hash
+	} else {
+		r.refs.branches[r.head] = hash
+	}
+	return hash
+}
+
+// resolve returns the commit hash of rev. Supported are HEAD, branches, notes refs, (abbreviated) hashes, and any
+// of these followed by ~n or ^ to select an ancestor
+func (r *Repository) resolve(rev string) (string, error) {
+	unknown := fmt.Errorf("fatal: ambiguous argument '%v': unknown revision or path not in the working tree.\n", rev)
+
+	base, generations := rev, 0
+	for {
+		if i := strings.LastIndexAny(base, "~^"); i >= 0 {
+			n := 1
+			if base[i] == '~' && i+1 < len(base) {
+				var err error
+				if n, err = strconv.Atoi(base[i+1:]); err != nil {
+					return "", unknown
+				}
+			} else if i+1 != len(base) {
+				return "", unknown
+			}
+			base, generations = base[:i], generations+n
+			continue
+		}
+		break
+	}
+
+	h, ok := r.resolveBase(base)
+	if !ok {
+		return "", unknown
+	}
+
+	for ; generations > 0; generations-- {
+		parents := r.store.commits[h].parents
+		if len(parents) == 0 {
+			return "", unknown
+		}
+		h = parents[0]
+	}
+	return h, nil
+}
+
+func (r *Repository) resolveBase(rev string) (string, bool) {
+	switch {
+	case rev == "HEAD":
+		return r.headHash()
+	case strings.HasPrefix(rev, notesRefPrefix):
+		h, ok := r.refs.notes[strings.TrimPrefix(rev, notesRefPrefix)]
+		return h, ok
+	}
+
+	if h, ok := r.refs.branches[strings.TrimPrefix(rev, headsRefPrefix)]; ok {
+		return h, true
+	}
+	if i := strings.Index(rev, "/"); i > 0 {
+		if remote, ok := r.remotes[rev[:i]]; ok {
+			h, ok := remote.refs.branches[rev[i+1:]]
+			return h, ok
+		}
+	}
+
+	if len(rev) < minPrefixLength {
+		return "", false
+	}
+	match := ""
+	for h := range r.store.commits {
+		if strings.HasPrefix(h, rev) {
+			if match != "" {
+				return "", false // Ambiguous
+			}
+			match = h
+		}
+	}
+	return match, match != ""
+}
+
+// updateNotes commits a modified copy of the notes onto the notes ref
+func (r *Repository) updateNotes(notesRef string, message string, modify func(notes map[string]string)) {
+	parents := []string{}
+	tip, ok := r.refs.notes[notesRef]
+	if ok {
+		parents = append(parents, tip)
+	}
+
+	notes := copyNotes(r.store.notes(tip))
+	modify(notes)
+	r.refs.notes[notesRef] = r.store.add(parents, message, notes).hash
+}
+
+// notes returns the notes held by a commit of a notes ref, or none if there's no such commit
+func (s *objectStore) notes(hash string) map[string]string {
+	if c, ok := s.commits[hash]; ok {
+		return c.notes
+	}
+	return map[string]string{}
+}
+
+func copyNotes(notes map[string]string) map[string]string {
+	c := make(map[string]string, len(notes))
+	for k, v := range notes {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,188 @@
+package gitfake
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testNotesRef = "gino_keva"
+	testRemote   = "origin"
+)
+
+func TestRevParse(t *testing.T) {
+	r := NewRepository()
+	first := r.Commit("First")
+	second := r.Commit("Second")
+	r.Branch("feature")
+	third := r.Commit("Third")
+
+	testCases := []struct {
+		rev    string
+		wanted string
+	}{
+		{rev: "HEAD", wanted: third},
+		{rev: "HEAD~1", wanted: second},
+		{rev: "HEAD^", wanted: second},
+		{rev: "HEAD~2", wanted: first},
+		{rev: "main", wanted: third},
+		{rev: "refs/heads/feature", wanted: second},
+		{rev: "feature~1", wanted: first},
+		{rev: first[:7], wanted: first},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.rev, func(t *testing.T) {
+			out, err := r.RevParse(tc.rev)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wanted+"\n", out)
+		})
+	}
+
+	_, err := r.RevParse("HEAD~3")
+	assert.Error(t, err)
+}
+
+func TestLogCommits(t *testing.T) {
+	r := NewRepository()
+	base := r.Commit("Base")
+	r.Branch("feature")
+	r.Checkout("feature")
+	feature := r.Commit("Feature")
+	r.Checkout("main")
+	main := r.Commit("Main")
+	merge, err := r.Merge("feature", "Merge feature")
+	assert.NoError(t, err)
+
+	hashes := []string{}
+	_, err = r.LogCommitsEach("HEAD", nil, func(hash string) bool {
+		hashes = append(hashes, hash)
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{merge, main, feature, base}, hashes)
+}
+
+func TestNotes(t *testing.T) {
+	r := NewRepository()
+	hash := r.Commit("First")
+
+	_, err := r.NotesShow(testNotesRef, hash)
+	assert.Error(t, err)
+
+	_, err = r.NotesAdd(testNotesRef, "HEAD", "note")
+	assert.NoError(t, err)
+
+	out, err := r.NotesShow(testNotesRef, hash)
+	assert.NoError(t, err)
+	assert.Equal(t, "note\n", out)
+
+	out, err = r.NotesList(testNotesRef)
+	assert.NoError(t, err)
+	assert.Equal(t, "519dd581e50e5b45d3b3c76c3172e9c3ec293488 "+hash+"\n", out) // Same blob hash as git
+
+	assert.Equal(t, map[string]string{hash: "note\n"}, r.Notes(testNotesRef))
+
+	err = r.ReadBlobs(func(readBlob func(hash string) (string, error)) error {
+		out, err := readBlob("519dd581e50e5b45d3b3c76c3172e9c3ec293488")
+		assert.NoError(t, err)
+		assert.Equal(t, "note\n", out)
+
+		_, err = readBlob(hash)
+		assert.Error(t, err, "A commit is no blob")
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestPushAndFetchNotes(t *testing.T) {
+	remote := NewRemote()
+	a := remote.Clone()
+	a.Commit("First")
+	a.PushBranch(DefaultBranch)
+	b := remote.Clone()
+
+	_, err := b.FetchNotes(testRemote, testNotesRef, false)
+	assert.Error(t, err, "Remote has no notes yet")
+
+	a.NotesAdd(testNotesRef, "HEAD", "a")
+	_, err = a.PushNotes(testRemote, testNotesRef)
+	assert.NoError(t, err)
+
+	b.NotesAdd(testNotesRef, "HEAD", "b")
+	out, err := b.PushNotes(testRemote, testNotesRef)
+	assert.Error(t, err, "Push is rejected since upstream changed")
+	assert.Contains(t, out, "! [rejected]")
+
+	out, err = b.FetchNotes(testRemote, testNotesRef, false)
+	assert.Error(t, err, "Fetch is rejected since local notes diverged")
+	assert.Contains(t, out, "! [rejected]")
+
+	_, err = b.FetchNotes(testRemote, testNotesRef, true)
+	assert.NoError(t, err)
+	assert.Equal(t, remote.Notes(testNotesRef), b.Notes(testNotesRef))
+}
+
+func TestMultipleRemotes(t *testing.T) {
+	remote := NewRemote()
+	a := remote.Clone()
+	a.Commit("First")
+	a.PushBranch(DefaultBranch)
+
+	mirror := remote.Mirror()
+	assert.NoError(t, a.AddRemote("mirror", mirror))
+	assert.Error(t, a.AddRemote("other", NewRemote()), "Unrelated remote doesn't share commits")
+
+	a.NotesAdd(testNotesRef, "HEAD", "a")
+	_, err := a.PushNotes("mirror", testNotesRef)
+	assert.NoError(t, err)
+	assert.Empty(t, remote.Notes(testNotesRef))
+	assert.Equal(t, a.Notes(testNotesRef), mirror.Notes(testNotesRef))
+
+	b := remote.Clone()
+	b.AddRemote("mirror", mirror)
+	_, err = b.FetchNotes("mirror", testNotesRef, false)
+	assert.NoError(t, err)
+	assert.Equal(t, mirror.Notes(testNotesRef), b.Notes(testNotesRef))
+
+	_, err = b.FetchNotes("unknown", testNotesRef, false)
+	assert.Error(t, err)
+}
+
+func TestNotesMerge(t *testing.T) {
+	remote := NewRemote()
+	a := remote.Clone()
+	first := a.Commit("First")
+	second := a.Commit("Second")
+	third := a.Commit("Third")
+	a.PushBranch(DefaultBranch)
+	for _, h := range []string{first, second, third} {
+		a.NotesAdd(testNotesRef, h, "base")
+	}
+	a.PushNotes(testRemote, testNotesRef)
+
+	b := remote.Clone()
+	b.NotesAdd(testNotesRef, third, "theirs")
+	b.NotesAdd(testNotesRef, first, "theirs")
+	b.PushNotes(testRemote, testNotesRef)
+
+	// Both sides change the note of the third commit, only theirs the first one, and only ours removes the second one
+	a.NotesAdd(testNotesRef, third, "ours")
+	a.updateNotes(testNotesRef, "Remove", func(notes map[string]string) { delete(notes, second) })
+
+	_, err := a.FetchNotesInto(testRemote, testNotesRef, "fetched")
+	assert.NoError(t, err)
+	_, err = a.NotesMerge(testNotesRef, "fetched")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{first: "theirs\n", third: "ours\n"}, a.Notes(testNotesRef))
+
+	_, err = a.DeleteNotesRef("fetched")
+	assert.NoError(t, err)
+	assert.Empty(t, a.Notes("fetched"))
+
+	_, err = a.PushNotes(testRemote, testNotesRef)
+	assert.NoError(t, err, "Merged notes fast-forward the remote")
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
+	"github.com/philips-software/gino-keva/pkg/gitfake"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ GitWrapper = &gitfake.Repository{}
+
+func runOn(t *testing.T, repo *gitfake.Repository, args ...string) (string, error) {
+	t.Helper()
+	ctx := ContextWithGitWrapper(context.Background(), repo)
+	return executeCommandContext(ctx, NewRootCommand(), args...)
+}
+
+func TestScenarioHistory(t *testing.T) {
+	repo := gitfake.NewRepository()
+
+	repo.Commit("First")
+	runOn(t, repo, "set", "version", "1.0.0", "--fetch=false")
+	repo.Branch("release")
+	repo.Commit("Second")
+	repo.Commit("Third")
+	runOn(t, repo, "set", "version", "2.0.0", "--fetch=false")
+
+	repo.Checkout("release")
+	repo.Commit("Hotfix")
+	repo.Checkout(gitfake.DefaultBranch)
+	runOn(t, repo, "set", "--at", "release", "hotfix", "true", "--fetch=false")
+
+	testCases := []struct {
+		rev        string
+		wantOutput string
+	}{
+		{rev: "HEAD", wantOutput: "version=2.0.0\n"},
+		{rev: "HEAD~1", wantOutput: "version=1.0.0\n"},
+		{rev: "release", wantOutput: "hotfix=true\nversion=1.0.0\n"},
+		{rev: "release~1", wantOutput: "version=1.0.0\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.rev, func(t *testing.T) {
+			gotOutput, err := runOn(t, repo, "list", "--at", tc.rev, "--fetch=false")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantOutput, gotOutput)
+		})
+	}
+}
+
+func TestScenarioMergedBranch(t *testing.T) {
+	repo := gitfake.NewRepository()
+
+	repo.Commit("Base")
+	runOn(t, repo, "set", "base", "1", "--fetch=false")
+	repo.Branch("feature")
+	repo.Checkout("feature")
+	repo.Commit("Feature")
+	runOn(t, repo, "set", "feature", "1", "--fetch=false")
+	repo.Checkout(gitfake.DefaultBranch)
+	repo.Commit("Main")
+	repo.Merge("feature", "Merge feature")
+
+	gotOutput, err := runOn(t, repo, "list", "--fetch=false")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "base=1\nfeature=1\n", gotOutput)
+}
+
+func TestScenarioConcurrentPush(t *testing.T) {
+	remote := gitfake.NewRemote()
+	a := remote.Clone()
+	a.Commit("First")
+	a.PushBranch(gitfake.DefaultBranch)
+	b := remote.Clone()
+
+	_, err := runOn(t, a, "set", "foo", "a", "--push")
+	assert.NoError(t, err)
+
+	// Without fetching first, the push is rejected since upstream changed
+	_, err = runOn(t, b, "set", "foo", "b", "--push", "--fetch=false")
+	assert.IsType(t, &ginokeva.UpstreamChanged{}, err)
+
+	// Fetching merges the unpushed local change with upstream, after which the push succeeds
+	_, err = runOn(t, b, "set", "foo", "b", "--push")
+	assert.NoError(t, err)
+
+	gotOutput, err := runOn(t, a, "get", "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", gotOutput)
+}
+
+func TestScenarioDivergedNotesAreMerged(t *testing.T) {
+	remote := gitfake.NewRemote()
+	a := remote.Clone()
+	a.Commit("First")
+	a.PushBranch(gitfake.DefaultBranch)
+	b := remote.Clone()
+
+	_, err := runOn(t, a, "set", "foo", "a", "--push")
+	assert.NoError(t, err)
+
+	// Work done offline isn't lost when upstream changed in the meanwhile
+	_, err = runOn(t, b, "set", "bar", "b", "--fetch=false")
+	assert.NoError(t, err)
+	_, err = runOn(t, b, "incr", "counter", "--fetch=false")
+	assert.NoError(t, err)
+
+	gotOutput, err := runOn(t, b, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar=b\ncounter=1\nfoo=a\n", gotOutput)
+
+	_, err = runOn(t, b, "incr", "counter", "--push")
+	assert.NoError(t, err)
+
+	gotOutput, err = runOn(t, a, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar=b\ncounter=2\nfoo=a\n", gotOutput)
+}
+
+func TestScenarioRetryDoesNotApplyChangesTwice(t *testing.T) {
+	remote := gitfake.NewRemote()
+	a := remote.Clone()
+	a.Commit("First")
+	a.PushBranch(gitfake.DefaultBranch)
+	b := remote.Clone()
+
+	_, err := runOn(t, a, "set", "foo", "a", "--push")
+	assert.NoError(t, err)
+
+	// Mimic the retry done by main: the rejected attempt is rolled back before starting again
+	backend := &gitBackend{GitWrapper: b}
+	backend.recordNotesTip(ginokeva.NewStore(b, ginokeva.Options{}))
+	_, err = runOn(t, b, "incr", "counter", "--push", "--fetch=false")
+	assert.IsType(t, &ginokeva.UpstreamChanged{}, err)
+	assert.NoError(t, backend.rollbackNotes(ginokeva.NewStore(b, ginokeva.Options{})))
+
+	_, err = runOn(t, b, "incr", "counter", "--push")
+	assert.NoError(t, err)
+
+	gotOutput, err := runOn(t, a, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, "counter=1\nfoo=a\n", gotOutput)
+}
+
+func TestScenarioPartiallyRejectedPushIsNotAppliedTwice(t *testing.T) {
+	origin := gitfake.NewRemote()
+	a := origin.Clone()
+	a.Commit("First")
+	a.PushBranch(gitfake.DefaultBranch)
+	mirror := origin.Mirror()
+	assert.NoError(t, a.AddRemote("mirror", mirror))
+	b := mirror.Clone()
+
+	_, err := runOn(t, a, "set", "counter", "10", "--push", "--remote", "origin,mirror")
+	assert.NoError(t, err)
+
+	// Only the mirror changed in the meanwhile, so only the mirror rejects the push
+	_, err = runOn(t, b, "set", "foo", "b", "--push")
+	assert.NoError(t, err)
+
+	_, err = runOn(t, a, "incr", "counter", "--push", "--fetch=false", "--remote", "origin,mirror")
+	assert.NoError(t, err)
+
+	for name, remote := range map[string]*gitfake.Remote{"origin": origin, "mirror": mirror} {
+		repo := remote.Clone()
+		gotOutput, err := runOn(t, repo, "get", "counter")
+		assert.NoError(t, err)
+		assert.Equal(t, "11", gotOutput, "Counter on %v", name)
+	}
+
+	gotOutput, err := runOn(t, a, "list", "--fetch=false")
+	assert.NoError(t, err)
+	assert.Equal(t, "counter=11\nfoo=b\n", gotOutput)
+}
+
+func TestScenarioPartiallyRejectedPushChecksPreconditionAgain(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mirrorKey string
+		wantErr   error
+	}{
+		{
+			name:      "Precondition still holds for the mirror",
+			mirrorKey: "other",
+			wantErr:   nil,
+		},
+		{
+			name:      "Precondition no longer holds for the mirror",
+			mirrorKey: "version",
+			wantErr:   &ginokeva.PreconditionFailed{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			origin := gitfake.NewRemote()
+			a := origin.Clone()
+			a.Commit("First")
+			a.PushBranch(gitfake.DefaultBranch)
+			mirror := origin.Mirror()
+			assert.NoError(t, a.AddRemote("mirror", mirror))
+			b := mirror.Clone()
+
+			_, err := runOn(t, a, "set", "version", "1", "--push", "--remote", "origin,mirror")
+			assert.NoError(t, err)
+
+			// Only the mirror changed in the meanwhile, so only the mirror rejects the push
+			_, err = runOn(t, b, "set", tc.mirrorKey, "2", "--push")
+			assert.NoError(t, err)
+
+			_, err = runOn(t, a, "cas", "version", "1", "3", "--push", "--fetch=false", "--remote", "origin,mirror")
+			if tc.wantErr != nil {
+				assert.IsType(t, tc.wantErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			gotOutput, err := runOn(t, mirror.Clone(), "get", tc.mirrorKey)
+			assert.NoError(t, err)
+			assert.Equal(t, "2", gotOutput, "Change on the mirror is kept")
+			if tc.wantErr == nil {
+				gotOutput, err = runOn(t, mirror.Clone(), "get", "version")
+				assert.NoError(t, err)
+				assert.Equal(t, "3", gotOutput)
+			}
+		})
+	}
+}
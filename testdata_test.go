@@ -1,8 +1,6 @@
 package main
 
 var (
-	TestDataDummyHash   = "DUMMY_HASH\n"
-	TestDataDummyRef    = "DUMMY_REF"
 	TestDataDummyValue  = "DUMMY_VALUE"
 	TestDataEmptyString = ""
 )
@@ -3,140 +3,189 @@ package main
 import (
 	"bytes"
 	"context"
-	"strconv"
-
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philips-software/gino-keva/internal/event"
+	"github.com/philips-software/gino-keva/pkg/ginokeva"
 	"github.com/spf13/cobra"
 )
 
-type notesStub struct {
-	fetchNotesImplementation   func(string) (string, error)
-	logCommitsImplementation   func() (string, error)
-	notesAddImplementation     func(string, string) (string, error)
-	notesListImplementation    func(string) (string, error)
-	notesShowImplementation    func(string, string) (string, error)
-	pushNotesImplementation    func(string) (string, error)
-	revParseHeadImplementation func() (string, error)
+func init() {
+	// Metadata depends on the environment the tests run in, so don't record any unless a test explicitly does
+	collectMetadata = func(GitWrapper, string) *event.Metadata { return nil }
 }
 
-// FetchNotes test-double
-func (n notesStub) FetchNotes(notesRef string, force bool) (string, error) {
-	return n.fetchNotesImplementation(notesRef)
+// notesStub is a GitWrapper for commands which don't touch git at all. Any git operation panics
+type notesStub struct {
+	GitWrapper
 }
 
-// LogCommits test-double
-func (n notesStub) LogCommits() (string, error) {
-	return n.logCommitsImplementation()
-}
+func executeCommandContext(ctx context.Context, root *cobra.Command, args ...string) (output string, err error) {
+	buf := new(bytes.Buffer)
 
-// NotesAdd test-double
-func (n notesStub) NotesAdd(notesRef string, msg string) (string, error) {
-	return n.notesAddImplementation(notesRef, msg)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(args)
+
+	err = root.ExecuteContext(ctx)
+	return buf.String(), err
 }
 
-// NotesList test-double
-func (n notesStub) NotesList(notesRef string) (string, error) {
-	return n.notesListImplementation(notesRef)
+func disableFetch(args []string) []string {
+	return append(args, "--fetch=false")
 }
 
-// NotesPrune dummy
-func (notesStub) NotesPrune(string) (string, error) {
-	return "", nil
+func atRev(args []string, rev string) []string {
+	return append(args, "--at", rev)
 }
 
-//NotesShow test-double calls the stub implementation
-func (n *notesStub) NotesShow(notesRef, hash string) (response string, err error) {
-	return n.notesShowImplementation(notesRef, hash)
+func enablePush(args []string) []string {
+	return append(args, "--push")
 }
 
-// PushNotes test-double
-func (n notesStub) PushNotes(notesRef string) (string, error) {
-	return n.pushNotesImplementation(notesRef)
+// testBackends are the git backends the command tests run against
+var testBackends = []string{backendCLI, backendNative}
+
+// forEachBackend runs the test against each of the git backends
+func forEachBackend(t *testing.T, test func(t *testing.T, backend string)) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) { test(t, backend) })
+	}
 }
 
-// RevParseHead test-double
-func (n notesStub) RevParseHead() (string, error) {
-	return n.revParseHeadImplementation()
+// testRepo is a git repository in a temporary directory, on which commands are executed using one of the backends
+type testRepo struct {
+	t        *testing.T
+	dir      string
+	backend  string
+	notesRef string
+	commits  int
 }
 
-var dummyStubArgsNone = func() (string, error) { return "", nil }
-var dummyStubArgsString = func(string) (string, error) { return "", nil }
-var dummyStubArgsStringString = func(string, string) (string, error) { return "", nil }
+// newTestRepo returns a repository with a single commit
+func newTestRepo(t *testing.T, backend string) *testRepo {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
 
-var responseStubArgsNone = func(expectedResponse string) func() (string, error) {
-	return func() (string, error) {
-		return expectedResponse, nil
+	r := &testRepo{t: t, dir: t.TempDir(), backend: backend, notesRef: ginokeva.DefaultNotesRef}
+	r.git("init", "-q")
+	r.git("config", "user.name", "Test")
+	r.git("config", "user.email", "test@example.com")
+	r.commit("Initial commit")
+	return r
+}
+
+// git runs git in the repository, and returns its output without surrounding whitespace
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v: %s", args, err, out)
 	}
+	return strings.TrimSpace(string(out))
 }
 
-var responseStubArgsString = func(expectedResponse string) func(string) (string, error) {
-	return func(string) (string, error) {
-		return expectedResponse, nil
-	}
+// commit adds an empty commit, and returns its hash. Each commit is authored one day after the previous one, starting
+// at 2022-01-01
+func (r *testRepo) commit(subject string) string {
+	date := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, r.commits)
+	r.commits++
+
+	r.git("commit", "-q", "--allow-empty", "-m", subject, "--date", date.Format(time.RFC3339))
+	return r.git("rev-parse", "HEAD")
 }
 
-var responseStubArgsStringString = func(expectedResponse string) func(string, string) (string, error) {
-	return func(string, string) (string, error) {
-		return expectedResponse, nil
+// setNote writes the events as note of the commit. Without events, the commit is left without note
+func (r *testRepo) setNote(rev string, events []event.Event) {
+	if len(events) == 0 {
+		return
 	}
-}
 
-var spyArgsString = func(isCalled *bool, arg1 *string) func(string) (string, error) {
-	return func(a1 string) (string, error) {
-		if isCalled != nil {
-			*isCalled = true
-		}
-		if arg1 != nil {
-			*arg1 = a1
-		}
-		return "", nil
+	eventsJSON, err := event.Marshal(&events)
+	if err != nil {
+		r.t.Fatal(err)
 	}
+	r.git("notes", "--ref", r.notesRef, "add", "-f", "-m", eventsJSON, rev)
 }
 
-var spyArgsStringString = func(isCalled *bool, arg1, arg2 *string) func(string, string) (string, error) {
-	return func(a1, a2 string) (string, error) {
-		if isCalled != nil {
-			*isCalled = true
-		}
-		if arg1 != nil {
-			*arg1 = a1
-		}
-		if arg2 != nil {
-			*arg2 = a2
-		}
-		return "", nil
+// note returns the note of the commit, or an empty string if there's none
+func (r *testRepo) note(rev string) string {
+	cmd := exec.Command("git", "notes", "--ref", r.notesRef, "show", rev)
+	cmd.Dir = r.dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
 	}
+	return strings.TrimSpace(string(out))
 }
 
-// Simple dummy responses for logCommits and notesList
-var (
-	simpleLogCommitsResponse = "COMMIT_REFERENCE\n"
-	simpleNotesListResponse  = "NOTES_OBJECT_ID COMMIT_REFERENCE\n"
-)
+// addRemote adds a new bare repository as remote, with the checked out branch pushed to it. Returns its directory
+func (r *testRepo) addRemote(name string) string {
+	dir := filepath.Join(r.t.TempDir(), name+".git")
+	r.git("init", "-q", "--bare", dir)
+	r.git("remote", "add", name, dir)
+	r.git("push", "-q", name, "HEAD")
+	return dir
+}
 
-func executeCommandContext(ctx context.Context, root *cobra.Command, args ...string) (output string, err error) {
-	buf := new(bytes.Buffer)
+// remoteNote returns the note of the commit on the remote, or an empty string if there's none
+func (r *testRepo) remoteNote(remoteDir string, rev string) string {
+	cmd := exec.Command("git", "--git-dir", remoteDir, "notes", "--ref", r.notesRef, "show", rev)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
 
-	root.SetOut(buf)
-	root.SetErr(buf)
-	root.SetArgs(args)
+// setRemoteNote writes the events as note of the commit on the remote, without changing the local notes
+func (r *testRepo) setRemoteNote(remote string, rev string, events []event.Event) {
+	notesRef := r.notesRef
+	defer func() { r.notesRef = notesRef }()
 
-	err = root.ExecuteContext(ctx)
-	return buf.String(), err
+	r.notesRef = "remote_note"
+	r.setNote(rev, events)
+	r.git("push", "-q", remote, "refs/notes/remote_note:refs/notes/"+notesRef)
+	r.git("update-ref", "-d", "refs/notes/remote_note")
 }
 
-func disableFetch(args []string) []string {
-	return append(args, "--fetch=false")
+// store returns a store on the repository using its backend
+func (r *testRepo) store() *ginokeva.Store {
+	chdir(r.t, r.dir)
+	backend := newGitBackend()
+	if err := backend.selectBackend(r.backend); err != nil {
+		r.t.Fatal(err)
+	}
+	return ginokeva.NewStore(backend, ginokeva.Options{NotesRef: r.notesRef})
 }
 
-func enablePush(args []string) []string {
-	return append(args, "--push")
+// run executes the command in the repository using its backend
+func (r *testRepo) run(args ...string) (string, error) {
+	return r.runWithInput("", args...)
 }
 
-func generateIncrementingNumbersListOfLength(length int) []string {
-	output := []string{}
-	for i := 0; i < length; i++ {
-		output = append(output, strconv.Itoa(i))
-	}
+// runWithInput executes the command in the repository using its backend, with the input on stdin
+func (r *testRepo) runWithInput(input string, args ...string) (string, error) {
+	chdir(r.t, r.dir)
+	root := NewRootCommand()
+	root.SetIn(strings.NewReader(input))
 
-	return output
+	ctx := ContextWithGitWrapper(context.Background(), newGitBackend())
+	return executeCommandContext(ctx, root, append(args, "--backend", r.backend)...)
+}
+
+// eventsJSON returns the events as written in a note
+func eventsJSON(t *testing.T, events []event.Event) string {
+	out, err := event.Marshal(&events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(out)
 }